@@ -1,21 +1,30 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pedroShimpa/cha-de-bebe-api/models"
-	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"github.com/pedroShimpa/cha-de-bebe-api/notifications"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-func Register(c *gin.Context, db *gorm.DB) {
+func Register(c *gin.Context, db *gorm.DB, mailer notifications.Mailer) {
 	var input models.User
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	input.VerifiedAt = nil
+
+	// Checked up front so no account is left without a way to confirm it.
+	if _, err := emailBaseURL(); err != nil {
+		log.Printf("cadastro: %v, e-mail de confirmação não pode ser enviado", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível concluir o cadastro agora, tente mais tarde"})
+		return
+	}
 
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(input.Senha), bcrypt.DefaultCost)
 	input.Senha = string(hashedPassword)
@@ -25,7 +34,11 @@ func Register(c *gin.Context, db *gorm.DB) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Usuário registrado com sucesso"})
+	if err := sendVerificationEmail(db, mailer, input); err != nil {
+		log.Printf("confirmação de e-mail do usuário %d: %v", input.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Usuário registrado com sucesso, confirme seu e-mail pelo link que enviamos"})
 }
 
 func Login(c *gin.Context, db *gorm.DB) {
@@ -50,8 +63,13 @@ func Login(c *gin.Context, db *gorm.DB) {
 		return
 	}
 
-	token, _ := utils.GenerateToken(user.ID)
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	session, err := issueSession(db, user, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível iniciar a sessão"})
+		return
+	}
+	session["verified"] = user.VerifiedAt != nil
+	c.JSON(http.StatusOK, session)
 }
 
 func CompletePrfile() {
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+)
+
+const defaultEventDuration = 3 * time.Hour
+
+// eventSchedule returns the event's start and end in its own timezone; events
+// without an end time are assumed to last a few hours.
+func eventSchedule(event models.Event) (time.Time, time.Time, error) {
+	if event.StartsAt.IsZero() {
+		return time.Time{}, time.Time{}, errors.New("Evento sem data definida")
+	}
+	loc, err := utils.LoadTimezone(event.Timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	start := event.StartsAt.In(loc)
+	end := start.Add(defaultEventDuration)
+	if event.EndsAt != nil {
+		end = event.EndsAt.In(loc)
+	}
+	return start, end, nil
+}
+
+func calendarEvent(c *gin.Context, event models.Event, link string) (utils.ICSEvent, error) {
+	start, end, err := eventSchedule(event)
+	if err != nil {
+		return utils.ICSEvent{}, err
+	}
+	return utils.ICSEvent{
+		UID:         fmt.Sprintf("evento-%d@%s", event.ID, c.Request.Host),
+		Start:       start,
+		End:         end,
+		Summary:     event.Title,
+		Description: event.Description,
+		Location:    event.Address,
+		URL:         link,
+		Cancelled:   event.Status == models.EventCancelled,
+	}, nil
+}
+
+// calendarLinks builds the "add to calendar" options shown on the invite page.
+func calendarLinks(c *gin.Context, event models.Event, inviteUUID string) gin.H {
+	start, end, err := eventSchedule(event)
+	if err != nil {
+		return nil
+	}
+	details := event.Description
+	if details != "" {
+		details += "\n\n"
+	}
+	details += inviteURL(c, inviteUUID)
+
+	google := url.Values{}
+	google.Set("action", "TEMPLATE")
+	google.Set("text", event.Title)
+	google.Set("dates", start.UTC().Format("20060102T150405Z")+"/"+end.UTC().Format("20060102T150405Z"))
+	google.Set("details", details)
+	google.Set("location", event.Address)
+	google.Set("ctz", start.Location().String())
+
+	outlook := url.Values{}
+	outlook.Set("path", "/calendar/action/compose")
+	outlook.Set("rru", "addevent")
+	outlook.Set("subject", event.Title)
+	outlook.Set("startdt", start.Format(time.RFC3339))
+	outlook.Set("enddt", end.Format(time.RFC3339))
+	outlook.Set("body", details)
+	outlook.Set("location", event.Address)
+
+	return gin.H{
+		"ics":     publicBaseURL(c) + "/invites/" + inviteUUID + "/event.ics",
+		"google":  "https://calendar.google.com/calendar/render?" + google.Encode(),
+		"outlook": "https://outlook.live.com/calendar/0/deeplink/compose?" + outlook.Encode(),
+	}
+}
+
+func sendICS(c *gin.Context, filename, body string) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ics"`, filename))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(body))
+}
+
+func (ctrl *Controller) GetInviteICS(c *gin.Context) {
+	inviteUUID := c.Param("uuid")
+
+	var invite models.EventInvited
+	if err := ctrl.DB.Where("uuid = ?", inviteUUID).First(&invite).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Convite não encontrado"})
+		return
+	}
+
+	var event models.Event
+	if err := ctrl.DB.First(&event, invite.EventID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+		return
+	}
+	if hideDraft(c, event) {
+		return
+	}
+
+	ics, err := calendarEvent(c, event, inviteURL(c, invite.UUID))
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendICS(c, "convite", utils.BuildICS(event.Title, []utils.ICSEvent{ics}))
+}
+
+// rotateCalendarToken gives the user a new feed token. With onlyIfMissing it keeps
+// a token set meanwhile by a concurrent request, so both answer the same URL.
+func (ctrl *Controller) rotateCalendarToken(user *models.User, onlyIfMissing bool) error {
+	token, err := utils.GenerateSecureToken(24)
+	if err != nil {
+		return err
+	}
+	query := ctrl.DB.Model(&models.User{}).Where("id = ?", user.ID)
+	if onlyIfMissing {
+		query = query.Where("calendar_token = '' OR calendar_token IS NULL")
+	}
+	if err := query.Update("calendar_token", token).Error; err != nil {
+		return err
+	}
+	return ctrl.DB.Select("calendar_token").First(user, user.ID).Error
+}
+
+func calendarFeedURL(c *gin.Context, user models.User) string {
+	return publicBaseURL(c) + "/calendar/" + user.CalendarToken + "/events.ics"
+}
+
+// GetCalendarFeedURL returns the user's feed URL, creating it on first use.
+func (ctrl *Controller) GetCalendarFeedURL(c *gin.Context) {
+	var user models.User
+	if err := ctrl.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+		return
+	}
+
+	if user.CalendarToken == "" {
+		if err := ctrl.rotateCalendarToken(&user, true); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar o link da agenda"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": calendarFeedURL(c, user)})
+}
+
+// ResetCalendarFeedURL replaces the feed URL, so a leaked one stops working.
+func (ctrl *Controller) ResetCalendarFeedURL(c *gin.Context) {
+	var user models.User
+	if err := ctrl.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+		return
+	}
+
+	if err := ctrl.rotateCalendarToken(&user, false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar o link da agenda"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": calendarFeedURL(c, user)})
+}
+
+func (ctrl *Controller) GetCalendarFeed(c *gin.Context) {
+	token := c.Param("token")
+
+	var user models.User
+	if token == "" || ctrl.DB.Where("calendar_token = ?", token).First(&user).Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agenda não encontrada"})
+		return
+	}
+
+	var events []models.Event
+	if err := ctrl.DB.Where("user_id = ? OR id IN (?)", user.ID, memberEventIDs(ctrl.DB, user.ID)).
+		Order("id").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar os eventos"})
+		return
+	}
+
+	entries := make([]utils.ICSEvent, 0, len(events))
+	for _, event := range events {
+		if ics, err := calendarEvent(c, event, ""); err == nil {
+			entries = append(entries, ics)
+		}
+	}
+
+	sendICS(c, "eventos", utils.BuildICS("Meus eventos", entries))
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+)
+
+func TestCalendarFeedResetRequiresPost(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	createEvent(t, ctrl.DB, owner.ID)
+
+	r := gin.New()
+	r.GET("/calendar/:token/events.ics", ctrl.GetCalendarFeed)
+	r.GET("/calendar/feed", withUser(owner.ID), ctrl.GetCalendarFeedURL)
+	r.POST("/calendar/feed/reset", withUser(owner.ID), ctrl.ResetCalendarFeedURL)
+
+	feedURL := func(method, path string) string {
+		w, body := doJSON(r, method, path, nil, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: status %d", method, path, w.Code)
+		}
+		return body["url"].(string)
+	}
+	feedStatus := func(raw string) int {
+		u, _ := url.Parse(raw)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u.Path, nil))
+		return w.Code
+	}
+
+	first := feedURL(http.MethodGet, "/calendar/feed")
+	if again := feedURL(http.MethodGet, "/calendar/feed?reset=true"); again != first {
+		t.Fatalf("GET trocou o link da agenda: %s -> %s", first, again)
+	}
+	if status := feedStatus(first); status != http.StatusOK {
+		t.Fatalf("agenda: status %d", status)
+	}
+
+	rotated := feedURL(http.MethodPost, "/calendar/feed/reset")
+	if rotated == first {
+		t.Fatal("POST não trocou o link da agenda")
+	}
+	if status := feedStatus(first); status != http.StatusNotFound {
+		t.Fatalf("link antigo ainda funciona: status %d", status)
+	}
+	if status := feedStatus(rotated); status != http.StatusOK {
+		t.Fatalf("link novo: status %d", status)
+	}
+}
+
+func TestCalendarFeedAcrossDSTChange(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	year := time.Now().Year() + 1
+	for _, month := range []time.Month{time.January, time.July} {
+		start := time.Date(year, month, 15, 15, 0, 0, 0, loc)
+		end := start.Add(3 * time.Hour)
+		event := models.Event{UserID: owner.ID, Status: models.EventPublished, Type: models.NotDefined,
+			Title: "Chá " + month.String(), Address: "Rua A, 1", StartsAt: start, EndsAt: &end, Timezone: loc.String()}
+		if err := ctrl.DB.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	token, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl.DB.Model(&owner).Update("calendar_token", token)
+
+	r := gin.New()
+	r.GET("/calendar/:token/events.ics", ctrl.GetCalendarFeed)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calendar/"+token+"/events.ics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+
+	// 15:00 in New York is 20:00 UTC in winter (EST) and 19:00 UTC in summer (EDT).
+	feed := w.Body.String()
+	for _, want := range []string{
+		fmt.Sprintf("DTSTART:%d0115T200000Z", year), fmt.Sprintf("DTEND:%d0115T230000Z", year),
+		fmt.Sprintf("DTSTART:%d0715T190000Z", year), fmt.Sprintf("DTEND:%d0715T220000Z", year),
+	} {
+		if !strings.Contains(feed, want) {
+			t.Fatalf("agenda sem %q:\n%s", want, feed)
+		}
+	}
+	if strings.Contains(feed, "VTIMEZONE") || strings.Contains(feed, "TZID") {
+		t.Fatalf("agenda com fuso fixo:\n%s", feed)
+	}
+}
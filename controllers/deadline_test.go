@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+)
+
+func TestRespondInviteAfterRSVPDeadline(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	invite := createInvite(t, ctrl.DB, event.ID, "Bia")
+	r := gin.New()
+	r.POST("/invites/:uuid/respond", ctrl.RespondInvite)
+
+	ctrl.DB.Model(&event).Update("rsvp_deadline", time.Now().Add(time.Hour))
+	if w, _ := doJSON(r, http.MethodPost, "/invites/"+invite.UUID+"/respond", gin.H{"accepted": true}, nil); w.Code != http.StatusOK {
+		t.Fatalf("antes do prazo, status %d: %s", w.Code, w.Body)
+	}
+
+	ctrl.DB.Model(&event).Update("rsvp_deadline", time.Now().Add(-time.Minute))
+	w, body := doJSON(r, http.MethodPost, "/invites/"+invite.UUID+"/respond", gin.H{"accepted": false}, nil)
+	if w.Code != http.StatusConflict || body["code"] != "rsvp_closed" {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if msg, _ := body["error"].(string); !strings.HasPrefix(msg, "O prazo para confirmar presença terminou em ") {
+		t.Fatalf("mensagem %q", msg)
+	}
+
+	var saved models.EventInvited
+	ctrl.DB.First(&saved, invite.ID)
+	if saved.Accepted == nil || !*saved.Accepted {
+		t.Fatalf("resposta alterada depois do prazo: %+v", saved.Accepted)
+	}
+}
+
+func TestReserveGiftAfterReservationDeadline(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	invite := createInvite(t, ctrl.DB, event.ID, "Bia")
+	gift := models.EventGift{EventID: event.ID, Name: "Carrinho", Kind: models.GiftItem, MaxReservations: 1}
+	ctrl.DB.Create(&gift)
+	r := gin.New()
+	r.POST("/gifts/reserve", ctrl.ReserveGift)
+
+	// The RSVP deadline alone doesn't close reservations.
+	ctrl.DB.Model(&event).Updates(map[string]interface{}{
+		"rsvp_deadline":        time.Now().Add(-time.Hour),
+		"reservation_deadline": time.Now().Add(-time.Minute),
+	})
+	w, body := doJSON(r, http.MethodPost, "/gifts/reserve", gin.H{"invite_uuid": invite.UUID, "event_gift_id": gift.ID}, nil)
+	if w.Code != http.StatusConflict || body["code"] != "reservation_deadline" {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	var reservations int64
+	ctrl.DB.Model(&models.GiftReservation{}).Where("event_gift_id = ?", gift.ID).Count(&reservations)
+	if reservations != 0 {
+		t.Fatalf("%d reserva(s) criada(s) depois do prazo", reservations)
+	}
+
+	ctrl.DB.Model(&event).Update("reservation_deadline", time.Now().Add(time.Hour))
+	if w, _ := doJSON(r, http.MethodPost, "/gifts/reserve", gin.H{"invite_uuid": invite.UUID, "event_gift_id": gift.ID}, nil); w.Code != http.StatusOK {
+		t.Fatalf("antes do prazo, status %d: %s", w.Code, w.Body)
+	}
+}
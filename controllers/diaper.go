@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DiaperPlanInput struct {
+	Targets map[models.DiaperSize]uint `json:"targets" binding:"required"`
+}
+
+type DiaperSizeSummary struct {
+	Size         models.DiaperSize `json:"size"`
+	TargetPacks  uint              `json:"target_packs"`
+	AssignedTo   int64             `json:"assigned_to"`
+	MissingPacks int64             `json:"missing_packs"`
+}
+
+// assignDiaperSize picks the size that is furthest behind its target, proportionally,
+// so the guests who accept are spread across the plan. The event row is locked so
+// concurrent RSVPs see each other's assignments.
+func assignDiaperSize(tx *gorm.DB, invite *models.EventInvited) error {
+	var event models.Event
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, invite.EventID).Error; err != nil {
+		return err
+	}
+
+	// Targets are inserted in DiaperSizes order, so ties go to the smaller size.
+	var targets []models.EventDiaperTarget
+	if err := tx.Where("event_id = ? AND target_packs > 0", invite.EventID).Order("id").Find(&targets).Error; err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	assigned, err := diaperAssignments(tx, invite.EventID)
+	if err != nil {
+		return err
+	}
+
+	var best *models.EventDiaperTarget
+	for i := range targets {
+		t := &targets[i]
+		// Compare assigned/target ratios without floating point.
+		if best == nil || assigned[t.Size]*int64(best.TargetPacks) < assigned[best.Size]*int64(t.TargetPacks) {
+			best = t
+		}
+	}
+
+	size := best.Size
+	invite.DiaperSize = &size
+	return nil
+}
+
+func diaperAssignments(tx *gorm.DB, eventID uint) (map[models.DiaperSize]int64, error) {
+	var rows []struct {
+		DiaperSize models.DiaperSize
+		Total      int64
+	}
+	if err := tx.Model(&models.EventInvited{}).
+		Select("diaper_size, COUNT(*) AS total").
+		Where("event_id = ? AND accepted = ? AND diaper_size IS NOT NULL", eventID, true).
+		Group("diaper_size").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	assigned := map[models.DiaperSize]int64{}
+	for _, r := range rows {
+		assigned[r.DiaperSize] = r.Total
+	}
+	return assigned, nil
+}
+
+func (ctrl *Controller) diaperSummary(eventID uint) ([]DiaperSizeSummary, error) {
+	var targets []models.EventDiaperTarget
+	if err := ctrl.DB.Where("event_id = ?", eventID).Find(&targets).Error; err != nil {
+		return nil, err
+	}
+	assigned, err := diaperAssignments(ctrl.DB, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetBySize := map[models.DiaperSize]uint{}
+	for _, t := range targets {
+		targetBySize[t.Size] = t.TargetPacks
+	}
+
+	summary := []DiaperSizeSummary{}
+	for _, size := range models.DiaperSizes {
+		target := targetBySize[size]
+		if target == 0 && assigned[size] == 0 {
+			continue
+		}
+		missing := int64(target) - assigned[size]
+		if missing < 0 {
+			missing = 0
+		}
+		summary = append(summary, DiaperSizeSummary{
+			Size:         size,
+			TargetPacks:  target,
+			AssignedTo:   assigned[size],
+			MissingPacks: missing,
+		})
+	}
+	return summary, nil
+}
+
+func (ctrl *Controller) GetDiaperPlan(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	summary, err := ctrl.diaperSummary(event.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar o plano de fraldas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sizes": summary})
+}
+
+func (ctrl *Controller) UpdateDiaperPlan(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	var input DiaperPlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for size := range input.Targets {
+		if !size.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tamanho de fralda inválido: " + string(size)})
+			return
+		}
+	}
+
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("event_id = ?", event.ID).Delete(&models.EventDiaperTarget{}).Error; err != nil {
+			return err
+		}
+		for _, size := range models.DiaperSizes {
+			packs, ok := input.Targets[size]
+			if !ok {
+				continue
+			}
+			target := models.EventDiaperTarget{EventID: event.ID, Size: size, TargetPacks: packs}
+			if err := tx.Create(&target).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível salvar o plano de fraldas"})
+		return
+	}
+
+	summary, err := ctrl.diaperSummary(event.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar o plano de fraldas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sizes": summary})
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+)
+
+func TestDiaperPlanSpreadsAcceptedGuests(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+
+	r := gin.New()
+	r.GET("/events/:id/diaper-plan", withUser(owner.ID), ctrl.GetDiaperPlan)
+	r.PUT("/events/:id/diaper-plan", withUser(owner.ID), ctrl.UpdateDiaperPlan)
+	r.POST("/invites/:uuid/respond", ctrl.RespondInvite)
+	path := fmt.Sprintf("/events/%d/diaper-plan", event.ID)
+
+	if w, _ := doJSON(r, http.MethodPut, path, gin.H{"targets": gin.H{"P": 2, "GG": 1}}, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("tamanho inválido aceito, status %d: %s", w.Code, w.Body)
+	}
+	if w, _ := doJSON(r, http.MethodPut, path, gin.H{"targets": gin.H{"RN": 0, "P": 2, "M": 4}}, nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	plan := func() []DiaperSizeSummary {
+		t.Helper()
+		w, _ := doJSON(r, http.MethodGet, path, nil, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+		var body struct {
+			Sizes []DiaperSizeSummary `json:"sizes"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return body.Sizes
+	}
+	respond := func(invite models.EventInvited, accepted bool) {
+		t.Helper()
+		if w, _ := doJSON(r, http.MethodPost, "/invites/"+invite.UUID+"/respond", gin.H{"accepted": accepted}, nil); w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+	}
+
+	// Each acceptance goes to the size furthest behind its target, so 6 guests
+	// fill a 2 P + 4 M plan exactly and the 7th goes back to P (3/2 vs 5/4).
+	var guests []models.EventInvited
+	for i := 0; i < 7; i++ {
+		invite := createInvite(t, ctrl.DB, event.ID, fmt.Sprintf("Convidado %d", i))
+		respond(invite, true)
+		guests = append(guests, invite)
+	}
+	respond(createInvite(t, ctrl.DB, event.ID, "Recusou"), false)
+
+	want := []DiaperSizeSummary{
+		{Size: models.DiaperP, TargetPacks: 2, AssignedTo: 3, MissingPacks: 0},
+		{Size: models.DiaperM, TargetPacks: 4, AssignedTo: 4, MissingPacks: 0},
+	}
+	if got := plan(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("plano %+v; esperava %+v", got, want)
+	}
+
+	// A guest who declines frees the size; M is now short of one pack.
+	var second models.EventInvited
+	ctrl.DB.First(&second, guests[1].ID)
+	if second.DiaperSize == nil || *second.DiaperSize != models.DiaperM {
+		t.Fatalf("segundo convidado com tamanho %v; esperava M", second.DiaperSize)
+	}
+	respond(guests[1], false)
+
+	want[1] = DiaperSizeSummary{Size: models.DiaperM, TargetPacks: 4, AssignedTo: 3, MissingPacks: 1}
+	if got := plan(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("plano %+v; esperava %+v", got, want)
+	}
+
+	// Sizes dropped from the plan still show the guests already assigned to them.
+	if w, _ := doJSON(r, http.MethodPut, path, gin.H{"targets": gin.H{"M": 4, "G": 3}}, nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	want = []DiaperSizeSummary{
+		{Size: models.DiaperP, TargetPacks: 0, AssignedTo: 3, MissingPacks: 0},
+		{Size: models.DiaperM, TargetPacks: 4, AssignedTo: 3, MissingPacks: 1},
+		{Size: models.DiaperG, TargetPacks: 3, AssignedTo: 0, MissingPacks: 3},
+	}
+	if got := plan(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("plano %+v; esperava %+v", got, want)
+	}
+}
@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateInvitedInput is a partial update: only the fields sent are changed.
+// Version must be the one the client read, so concurrent edits are detected.
+type UpdateInvitedInput struct {
+	Version       uint    `json:"version" binding:"required"`
+	Name          *string `json:"name,omitempty"`
+	Whatsapp      *string `json:"whatsapp,omitempty"`
+	Email         *string `json:"email,omitempty"`
+	MaxCompanions *uint   `json:"max_companions,omitempty"`
+}
+
+type UpdateGiftInput struct {
+	Version         uint    `json:"version" binding:"required"`
+	Name            *string `json:"name,omitempty"`
+	Link            *string `json:"link,omitempty"`
+	MaxReservations *uint   `json:"max_reservations,omitempty"`
+	TargetCents     *int64  `json:"target_cents,omitempty"`
+}
+
+var (
+	errVersionConflict = errors.New("Este item foi alterado por outra pessoa, recarregue e tente novamente")
+	errNameRequired    = errors.New("O nome não pode ficar vazio")
+	errBelowReserved   = errors.New("O limite de reservas não pode ser menor que as reservas já feitas")
+	errBelowCompanions = errors.New("O limite de acompanhantes não pode ser menor que os acompanhantes já informados")
+	errInvalidLimit    = errors.New("O limite de reservas deve ser maior que zero")
+	errFundHasNoLimit  = errors.New("Fundos não possuem limite de reservas")
+	errInvalidTarget   = errors.New("Informe a meta do fundo em centavos")
+)
+
+func respondEditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "version_conflict"})
+	case errors.Is(err, errBelowReserved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "below_reserved"})
+	case errors.Is(err, errBelowCompanions):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "below_companions"})
+	case errors.Is(err, errNameRequired),
+		errors.Is(err, errInvalidLimit),
+		errors.Is(err, errFundHasNoLimit),
+		errors.Is(err, errInvalidTarget),
+		errors.Is(err, errGiftIsNotFund):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível salvar as alterações"})
+	}
+}
+
+// findEventInvite resolves a guest of the event either by numeric ID or by invite UUID.
+func findEventInvite(db *gorm.DB, eventID uint, ref string) (models.EventInvited, error) {
+	query := db.Where("event_id = ?", eventID)
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("uuid = ?", ref)
+	}
+
+	var invite models.EventInvited
+	err := query.First(&invite).Error
+	return invite, err
+}
+
+// saveVersioned applies the changes only while the row is still at the version the
+// client read, bumping it; no affected rows means someone else saved first. Guests
+// and gifts carry a version bumped on every organizer edit for this check; guests'
+// own RSVP writes leave it alone.
+func saveVersioned(tx *gorm.DB, model interface{}, id, version uint, changes map[string]interface{}) error {
+	changes["version"] = gorm.Expr("version + 1")
+	result := tx.Model(model).Where("id = ? AND version = ?", id, version).Updates(changes)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+	return nil
+}
+
+// companionsNeeded is how many companions the guest already counts on: the
+// confirmed headcount besides the guest, or the names given if more.
+func companionsNeeded(invite models.EventInvited) int {
+	needed := len(invite.Companions)
+	if invite.Accepted != nil && *invite.Accepted {
+		if confirmed := int(invite.Adults) + int(invite.Children) - 1; confirmed > needed {
+			needed = confirmed
+		}
+	}
+	return needed
+}
+
+func (ctrl *Controller) UpdateInvited(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	var input UpdateInvitedInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invite, err := findEventInvite(ctrl.DB, event.ID, c.Param("invite_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Convidado não encontrado"})
+		return
+	}
+
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		changes := map[string]interface{}{}
+		if input.Name != nil {
+			name := strings.TrimSpace(*input.Name)
+			if name == "" {
+				return errNameRequired
+			}
+			changes["name"] = name
+		}
+		if input.Whatsapp != nil {
+			changes["whatsapp"] = utils.NormalizePhone(*input.Whatsapp)
+		}
+		if input.Email != nil {
+			changes["email"] = strings.ToLower(strings.TrimSpace(*input.Email))
+		}
+		if input.MaxCompanions != nil {
+			// Locked so an RSVP can't raise the headcount past the new limit meanwhile.
+			var current models.EventInvited
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Companions").First(&current, invite.ID).Error; err != nil {
+				return err
+			}
+			if companionsNeeded(current) > int(*input.MaxCompanions) {
+				return errBelowCompanions
+			}
+			changes["max_companions"] = *input.MaxCompanions
+		}
+
+		if err := saveVersioned(tx, &models.EventInvited{}, invite.ID, input.Version, changes); err != nil {
+			return err
+		}
+		return tx.Preload("Companions").First(&invite, invite.ID).Error
+	})
+	if err != nil {
+		respondEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invited": invite})
+}
+
+func (ctrl *Controller) UpdateGift(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	var input UpdateGiftInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var gift models.EventGift
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		// Same lock taken by reservations, so the count below can't go stale.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND event_id = ?", c.Param("gift_id"), event.ID).
+			First(&gift).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errGiftNotFound
+			}
+			return err
+		}
+
+		changes := map[string]interface{}{}
+		if input.Name != nil {
+			name := strings.TrimSpace(*input.Name)
+			if name == "" {
+				return errNameRequired
+			}
+			changes["name"] = name
+		}
+		if input.Link != nil {
+			changes["link"] = strings.TrimSpace(*input.Link)
+		}
+		if input.MaxReservations != nil {
+			if gift.Kind == models.GiftFund {
+				return errFundHasNoLimit
+			}
+			if *input.MaxReservations == 0 {
+				return errInvalidLimit
+			}
+			var reserved int64
+			if err := tx.Model(&models.GiftReservation{}).Where("event_gift_id = ?", gift.ID).Count(&reserved).Error; err != nil {
+				return err
+			}
+			if uint(reserved) > *input.MaxReservations {
+				return errBelowReserved
+			}
+			changes["max_reservations"] = *input.MaxReservations
+		}
+		if input.TargetCents != nil {
+			if gift.Kind != models.GiftFund {
+				return errGiftIsNotFund
+			}
+			if *input.TargetCents <= 0 {
+				return errInvalidTarget
+			}
+			changes["target_cents"] = *input.TargetCents
+		}
+
+		if err := saveVersioned(tx, &models.EventGift{}, gift.ID, input.Version, changes); err != nil {
+			return err
+		}
+		return tx.Preload("Reservations").First(&gift, gift.ID).Error
+	})
+	if errors.Is(err, errGiftNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		respondEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gift": gift})
+}
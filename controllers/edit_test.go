@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"gorm.io/gorm"
+)
+
+func TestUpdateInvitedKeepsConfirmedHeadcount(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	invite := createInvite(t, ctrl.DB, event.ID, "Ana")
+	ctrl.DB.Model(&invite).Update("max_companions", 3)
+
+	r := gin.New()
+	r.POST("/invites/:uuid/respond", ctrl.RespondInvite)
+	r.PATCH("/events/:id/invited/:invite_id", withUser(owner.ID), ctrl.UpdateInvited)
+
+	// Four people confirmed, without naming the companions.
+	w, _ := doJSON(r, http.MethodPost, "/invites/"+invite.UUID+"/respond", gin.H{"accepted": true, "adults": 2, "children": 2}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	path := fmt.Sprintf("/events/%d/invited/%d", event.ID, invite.ID)
+	w, body := doJSON(r, http.MethodPatch, path, gin.H{"version": 1, "max_companions": 1}, nil)
+	if w.Code != http.StatusConflict || body["code"] != "below_companions" {
+		t.Fatalf("limite abaixo das pessoas confirmadas: status %d: %s", w.Code, w.Body)
+	}
+	if w, _ := doJSON(r, http.MethodPatch, path, gin.H{"version": 1, "max_companions": 3}, nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestRespondInviteKeepsConcurrentOrganizerEdit(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	invite := createInvite(t, ctrl.DB, event.ID, "Ana")
+
+	// The organizer renames the guest right after the RSVP request loaded the invite.
+	var once sync.Once
+	ctrl.DB.Callback().Query().After("gorm:query").Register("test:concurrent_edit", func(tx *gorm.DB) {
+		if tx.Statement.Table != "event_inviteds" {
+			return
+		}
+		once.Do(func() {
+			ctrl.DB.Exec("UPDATE event_inviteds SET name = ?, version = version + 1 WHERE id = ?", "Ana Souza", invite.ID)
+		})
+	})
+
+	r := gin.New()
+	r.POST("/invites/:uuid/respond", ctrl.RespondInvite)
+	if w, _ := doJSON(r, http.MethodPost, "/invites/"+invite.UUID+"/respond", gin.H{"accepted": true}, nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	var saved models.EventInvited
+	ctrl.DB.First(&saved, invite.ID)
+	if saved.Name != "Ana Souza" || saved.Version != 2 {
+		t.Fatalf("edição do organizador sobrescrita pela resposta: %q versão %d", saved.Name, saved.Version)
+	}
+	if saved.Accepted == nil || !*saved.Accepted || saved.Adults != 1 {
+		t.Fatalf("resposta não salva: %+v", saved)
+	}
+}
@@ -1,14 +1,19 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/notifications"
 	"github.com/pedroShimpa/cha-de-bebe-api/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CreateEventInput struct {
@@ -16,32 +21,152 @@ type CreateEventInput struct {
 	Title       string               `json:"title" binding:"required"`
 	Description string               `json:"description"`
 	PixKey      string               `json:"pix_key"`
+	PixName     string               `json:"pix_name"`
+	PixCity     string               `json:"pix_city"`
 	EventDate   string               `json:"event_date" binding:"required"`
-	HourStart   string               `json:"hour_start" binding:"required"`
+	HourStart   string               `json:"hour_start"`
 	HourEnd     string               `json:"hour_end"`
+	EndDate     string               `json:"end_date"`
+	Overnight   bool                 `json:"overnight"`
+	Timezone    string               `json:"timezone"`
 	Address     string               `json:"address" binding:"required"`
 	Invited     []CreateInvitedInput `json:"invited"`
 	Gifts       []CreateGiftInput    `json:"gifts"`
+
+	// Deadlines left out of an update keep their current value; "" clears them.
+	RSVPDeadline        *string `json:"rsvp_deadline"`
+	ReservationDeadline *string `json:"reservation_deadline"`
+}
+
+var (
+	errEventInPast       = errors.New("A data do evento não pode estar no passado")
+	errTooManyCompanions = errors.New("O convite não permite tantos acompanhantes")
+)
+
+// parseSchedule validates the date and hours sent by the organizer, accepting
+// dd/mm/yyyy or ISO dates, and returns them as timestamps in the event's timezone.
+func parseSchedule(input CreateEventInput) (time.Time, *time.Time, string, error) {
+	timezone := input.Timezone
+	if timezone == "" {
+		timezone = utils.DefaultTimezone
+	}
+	start, end, err := utils.ParseEventSchedule(utils.EventSchedule{
+		Date:      input.EventDate,
+		HourStart: input.HourStart,
+		HourEnd:   input.HourEnd,
+		EndDate:   input.EndDate,
+		Overnight: input.Overnight,
+		Timezone:  timezone,
+	})
+	return start, end, timezone, err
+}
+
+var errDeadlineAfterEvent = errors.New("Os prazos devem terminar antes do início do evento")
+
+// parseDeadlines reads the RSVP and gift reservation cut-offs, which must fall
+// before the event starts. Deadlines not sent are taken from current.
+func parseDeadlines(input CreateEventInput, timezone string, startsAt time.Time, current models.Event) (*time.Time, *time.Time, error) {
+	rsvp, err := parseDeadline(input.RSVPDeadline, current.RSVPDeadline, timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+	reservation, err := parseDeadline(input.ReservationDeadline, current.ReservationDeadline, timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, deadline := range []*time.Time{rsvp, reservation} {
+		if deadline != nil && deadline.After(startsAt) {
+			return nil, nil, errDeadlineAfterEvent
+		}
+	}
+	return rsvp, reservation, nil
 }
 
+func parseDeadline(value *string, current *time.Time, timezone string) (*time.Time, error) {
+	if value == nil {
+		return current, nil
+	}
+	return utils.ParseDeadline(*value, timezone)
+}
+
+// CreateInvitedInput describes a single guest or, when Members is set, a
+// household sharing one invite link under Name.
 type CreateInvitedInput struct {
-	UserID *uint  `json:"user_id,omitempty"`
-	Name   string `json:"name" binding:"required"`
+	UserID        *uint                `json:"user_id,omitempty"`
+	Name          string               `json:"name" binding:"required"`
+	Whatsapp      string               `json:"whatsapp,omitempty"`
+	Email         string               `json:"email,omitempty"`
+	MaxCompanions uint                 `json:"max_companions,omitempty"`
+	Members       []CreateInvitedInput `json:"members,omitempty"`
+
+	// ID and Version identify an existing guest when the event is updated; with
+	// Members, ID is the existing household's group and takes no version.
+	ID      uint `json:"id,omitempty"`
+	Version uint `json:"version,omitempty"`
+}
+
+type RespondInviteInput struct {
+	Accepted   *bool    `json:"accepted" binding:"required"`
+	Adults     *uint    `json:"adults,omitempty" binding:"omitempty,max=50"`
+	Children   uint     `json:"children,omitempty" binding:"max=50"`
+	Companions []string `json:"companions,omitempty"`
+}
+
+type Headcount struct {
+	Adults   int64 `json:"adults"`
+	Children int64 `json:"children"`
+	Total    int64 `json:"total"`
 }
 
 type CreateGiftInput struct {
-	Name            string `json:"name" binding:"required"`
-	Link            string `json:"link,omitempty"`
-	MaxReservations string `json:"max_reservations,omitempty"`
+	Name            string          `json:"name" binding:"required"`
+	Link            string          `json:"link,omitempty"`
+	MaxReservations string          `json:"max_reservations,omitempty"`
+	Kind            models.GiftKind `json:"kind,omitempty"`
+	TargetCents     int64           `json:"target_cents,omitempty"`
+
+	// ID and Version identify an existing gift when the event is updated.
+	ID      uint `json:"id,omitempty"`
+	Version uint `json:"version,omitempty"`
 }
 
-type ReserveGiftInput struct {
-	InviteUUID  string `json:"invite_uuid" binding:"required"`
-	EventGiftID uint   `json:"event_gift_id" binding:"required"`
+var errInvalidGiftKind = errors.New("Tipo de presente inválido")
+
+func buildGift(eventID uint, input CreateGiftInput) (models.EventGift, error) {
+	gift := models.EventGift{
+		EventID:         eventID,
+		Name:            input.Name,
+		Link:            input.Link,
+		Kind:            models.GiftItem,
+		MaxReservations: 1,
+	}
+
+	switch input.Kind {
+	case "", models.GiftItem:
+		if input.MaxReservations != "" {
+			v, err := strconv.Atoi(input.MaxReservations)
+			if err != nil || v <= 0 {
+				return gift, errInvalidLimit
+			}
+			gift.MaxReservations = uint(v)
+		}
+	case models.GiftFund:
+		if input.TargetCents <= 0 {
+			return gift, errInvalidTarget
+		}
+		gift.Kind = models.GiftFund
+		gift.TargetCents = input.TargetCents
+	default:
+		return gift, errInvalidGiftKind
+	}
+
+	return gift, nil
 }
 
 type Controller struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Notifier notifications.Notifier
+	Mailer   notifications.Mailer
 }
 
 func (ctrl *Controller) CreateEvent(c *gin.Context) {
@@ -51,56 +176,89 @@ func (ctrl *Controller) CreateEvent(c *gin.Context) {
 		return
 	}
 
+	if input.PixKey != "" {
+		key, _, err := utils.NormalizePixKey(input.PixKey)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		input.PixKey = key
+	}
+
+	startsAt, endsAt, timezone, err := parseSchedule(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if startsAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errEventInPast.Error()})
+		return
+	}
+	rsvpDeadline, reservationDeadline, err := parseDeadlines(input, timezone, startsAt, models.Event{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if fields := validateNestedInputs(input); len(fields) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alguns convidados ou presentes são inválidos", "fields": fields})
+		return
+	}
+
 	userID := c.GetUint("userID")
 
 	event := models.Event{
 		UserID:      userID,
+		Status:      models.EventDraft,
 		Type:        input.Type,
 		Title:       input.Title,
 		Description: input.Description,
 		PixKey:      input.PixKey,
-		EventDate:   input.EventDate,
-		HourStart:   input.HourStart,
-		HourEnd:     input.HourEnd,
+		PixName:     input.PixName,
+		PixCity:     input.PixCity,
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
+		Timezone:    timezone,
 		Address:     input.Address,
-	}
 
-	if err := ctrl.DB.Create(&event).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		RSVPDeadline:        rsvpDeadline,
+		ReservationDeadline: reservationDeadline,
 	}
 
-	for _, inv := range input.Invited {
-		uuid := utils.GenerateCustomUUID()
-		invited := models.EventInvited{
-			EventID: event.ID,
-			UserID:  inv.UserID,
-			Name:    inv.Name,
-			UUID:    uuid,
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&event).Error; err != nil {
+			return err
 		}
-		ctrl.DB.Create(&invited)
-	}
-
-	for _, gift := range input.Gifts {
-		maxRes := uint(1)
-		if gift.MaxReservations != "" {
-			if v, err := strconv.Atoi(gift.MaxReservations); err == nil && v > 0 {
-				maxRes = uint(v)
+		for i, inv := range input.Invited {
+			if len(inv.Members) > 0 {
+				if _, err := createInviteGroup(tx, event.ID, inv); err != nil {
+					return fmt.Errorf("invited[%d]: %w", i, err)
+				}
+				continue
+			}
+			invited := newInvited(event.ID, inv)
+			if err := tx.Create(&invited).Error; err != nil {
+				return fmt.Errorf("invited[%d]: %w", i, err)
 			}
 		}
-
-		newGift := models.EventGift{
-			EventID:         event.ID,
-			Name:            gift.Name,
-			Link:            gift.Link,
-			MaxReservations: maxRes,
+		for i, g := range input.Gifts {
+			gift, err := buildGift(event.ID, g)
+			if err != nil {
+				return fmt.Errorf("gifts[%d]: %w", i, err)
+			}
+			if err := tx.Create(&gift).Error; err != nil {
+				return fmt.Errorf("gifts[%d]: %w", i, err)
+			}
 		}
-
-		ctrl.DB.Create(&newGift)
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível criar o evento, nenhuma alteração foi salva"})
+		return
 	}
 
 	var createdEvent models.Event
-	if err := ctrl.DB.Preload("Invited").Preload("Gifts.Reservations").
+	if err := ctrl.DB.Preload("Invited").Preload("Groups.Members").Preload("Gifts.Reservations").
 		First(&createdEvent, event.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -110,13 +268,28 @@ func (ctrl *Controller) CreateEvent(c *gin.Context) {
 }
 
 func (ctrl *Controller) GetEvent(c *gin.Context) {
-	eventID := c.Param("id")
-	var event models.Event
-	if err := ctrl.DB.Preload("Invited").Preload("Gifts.Reservations").First(&event, eventID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+	event, ok := ctrl.authorizeEvent(c, models.RoleViewer, "Invited.Companions", "Groups.Members", "Gifts.Reservations")
+	if !ok {
+		return
+	}
+
+	headcount, err := eventHeadcount(ctrl.DB, event.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível calcular o número de convidados"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"event": event})
+
+	c.JSON(http.StatusOK, gin.H{"event": event, "headcount": headcount})
+}
+
+func eventHeadcount(db *gorm.DB, eventID uint) (Headcount, error) {
+	var headcount Headcount
+	err := db.Model(&models.EventInvited{}).
+		Select("COALESCE(SUM(adults), 0) AS adults, COALESCE(SUM(children), 0) AS children").
+		Where("event_id = ? AND accepted = ?", eventID, true).
+		Scan(&headcount).Error
+	headcount.Total = headcount.Adults + headcount.Children
+	return headcount, err
 }
 
 func (ctrl *Controller) RespondInvite(c *gin.Context) {
@@ -128,141 +301,275 @@ func (ctrl *Controller) RespondInvite(c *gin.Context) {
 		return
 	}
 
-	var input struct {
-		Accepted bool `json:"accepted" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var event models.Event
+	if err := ctrl.DB.First(&event, invite.EventID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
 		return
 	}
-
-	now := time.Now()
-	invite.Accepted = &input.Accepted
-	invite.RespondedAt = &now
-
-	if err := ctrl.DB.Save(&invite).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível salvar resposta do convite"})
+	if !event.RSVPOpen(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": rsvpClosedMessage(event), "code": "rsvp_closed"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"invite": invite})
-}
-
-func (ctrl *Controller) ReserveGift(c *gin.Context) {
-	var input ReserveGiftInput
+	var input RespondInviteInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var gift models.EventGift
-	if err := ctrl.DB.Preload("Reservations").First(&gift, input.EventGiftID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Presente não encontrado"})
-		return
+	var companions []models.InvitedCompanion
+	var adults, children uint
+	extra := 0
+	if *input.Accepted {
+		adults = 1
+		if input.Adults != nil {
+			adults = *input.Adults
+		}
+		if adults == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Informe ao menos um adulto"})
+			return
+		}
+		children = input.Children
+		// Computed in int so a bad combination can't wrap around.
+		extra = int(adults) + int(children) - 1
+		if extra > int(invite.MaxCompanions) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Este convite permite no máximo %d acompanhante(s)", invite.MaxCompanions)})
+			return
+		}
+		if len(input.Companions) > extra {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Há mais nomes de acompanhantes do que pessoas confirmadas"})
+			return
+		}
+		for _, name := range input.Companions {
+			if name = strings.TrimSpace(name); name != "" {
+				companions = append(companions, models.InvitedCompanion{EventInvitedID: invite.ID, Name: name})
+			}
+		}
 	}
 
-	if uint(len(gift.Reservations)) >= gift.MaxReservations {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Limite de reservas atingido"})
-		return
-	}
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if !*input.Accepted {
+			invite.DiaperSize = nil
+		} else if invite.DiaperSize == nil {
+			if err := assignDiaperSize(tx, &invite); err != nil {
+				return err
+			}
+		}
 
-	for _, r := range gift.Reservations {
-		if r.InviteUUID == input.InviteUUID {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Você já reservou este presente"})
-			return
+		// Only the RSVP columns are written, so an organizer edit saved meanwhile
+		// is kept; the limit is checked again in case it was lowered.
+		result := tx.Model(&models.EventInvited{}).
+			Where("id = ? AND max_companions >= ?", invite.ID, extra).
+			Updates(map[string]interface{}{
+				"accepted":     *input.Accepted,
+				"responded_at": time.Now(),
+				"adults":       adults,
+				"children":     children,
+				"diaper_size":  invite.DiaperSize,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errTooManyCompanions
 		}
-	}
 
-	reservation := models.GiftReservation{
-		EventGiftID: input.EventGiftID,
-		InviteUUID:  input.InviteUUID,
+		if err := tx.Unscoped().Where("event_invited_id = ?", invite.ID).Delete(&models.InvitedCompanion{}).Error; err != nil {
+			return err
+		}
+		if len(companions) > 0 {
+			if err := tx.Create(&companions).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Preload("Companions").First(&invite, invite.ID).Error
+	})
+	if errors.Is(err, errTooManyCompanions) {
+		ctrl.DB.Select("max_companions").First(&invite, invite.ID)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Este convite permite no máximo %d acompanhante(s)", invite.MaxCompanions)})
+		return
 	}
-	if err := ctrl.DB.Create(&reservation).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível reservar o presente"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível salvar resposta do convite"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Presente reservado com sucesso"})
+	c.JSON(http.StatusOK, gin.H{"invite": invite})
 }
 
 func (ctrl *Controller) GetEventByInvite(c *gin.Context) {
 	inviteUUID := c.Param("uuid")
 
 	var invite models.EventInvited
-	if err := ctrl.DB.Where("uuid = ?", inviteUUID).First(&invite).Error; err != nil {
+	if err := ctrl.DB.Preload("Companions").Where("uuid = ?", inviteUUID).First(&invite).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Convite não encontrado"})
 		return
 	}
 
+	ctrl.respondInviteEvent(c, invite, gin.H{})
+}
+
+// respondInviteEvent renders the guest view of the invite's event: gifts that are
+// still available plus what the guest already reserved.
+func (ctrl *Controller) respondInviteEvent(c *gin.Context, invite models.EventInvited, extra gin.H) {
 	var event models.Event
 	if err := ctrl.DB.Preload("Gifts.Reservations").
 		First(&event, invite.EventID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
 		return
 	}
+	if hideDraft(c, event) {
+		return
+	}
+
+	myReservations := []models.GiftReservation{}
+	if err := ctrl.DB.Preload("Gift").
+		Where("invite_uuid = ?", invite.UUID).
+		Order("created_at").
+		Find(&myReservations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar suas reservas"})
+		return
+	}
+
+	reservedByMe := map[uint]bool{}
+	for _, r := range myReservations {
+		reservedByMe[r.EventGiftID] = true
+	}
 
 	availableGifts := []models.EventGift{}
 	for _, gift := range event.Gifts {
-		if len(gift.Reservations) < int(gift.MaxReservations) {
+		if gift.Kind == models.GiftFund {
+			availableGifts = append(availableGifts, gift)
+			continue
+		}
+		if len(gift.Reservations) < int(gift.MaxReservations) && !reservedByMe[gift.ID] {
 			availableGifts = append(availableGifts, gift)
 		}
 	}
 	event.Gifts = availableGifts
 	event.Invited = nil
 
-	c.JSON(http.StatusOK, gin.H{"event": event, "invite": invite})
+	response := gin.H{
+		"event":           event,
+		"invite":          invite,
+		"my_reservations": myReservations,
+		"calendar_links":  calendarLinks(c, event, invite.UUID),
+		"deadlines": gin.H{
+			"rsvp":              event.RSVPDeadline,
+			"reservation":       event.ReservationDeadline,
+			"rsvp_open":         event.RSVPOpen(time.Now()),
+			"reservations_open": event.ReservationsOpen(time.Now()),
+		},
+	}
+	for k, v := range extra {
+		response[k] = v
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 func (ctrl *Controller) UpdateEvent(c *gin.Context) {
-	eventID := c.Param("id")
-	userID := c.GetUint("userID")
-
-	var event models.Event
-	if err := ctrl.DB.First(&event, eventID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor, "Invited.Companions", "Groups", "Gifts.Reservations", "Gifts.Pledges")
+	if !ok {
 		return
 	}
 
-	if event.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas o criador pode editar o evento"})
+	var input CreateEventInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var input CreateEventInput
-	if err := c.ShouldBindJSON(&input); err != nil {
+	if input.PixKey != "" {
+		key, _, err := utils.NormalizePixKey(input.PixKey)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		input.PixKey = key
+	}
+
+	startsAt, endsAt, timezone, err := parseSchedule(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !startsAt.Equal(event.StartsAt) && startsAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errEventInPast.Error()})
+		return
+	}
+	rsvpDeadline, reservationDeadline, err := parseDeadlines(input, timezone, startsAt, event)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Guests and gifts are reconciled with the lists sent: entries without an ID
+	// are created, the ones with an ID updated and the missing ones deleted.
+	fields := validateNestedInputs(input)
+	var sync nestedSync
+	sync.planInvitedSync(event, input.Invited, fields)
+	blocked := sync.planGiftSync(event, input.Gifts, c.Query("force") == "true", fields)
+	if len(fields) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alguns convidados ou presentes são inválidos", "fields": fields})
+		return
+	}
+	if len(blocked) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Alguns presentes removidos já foram reservados por convidados, use force=true para removê-los mesmo assim",
+			"gifts": blocked,
+		})
+		return
+	}
+
 	event.Title = input.Title
 	event.Description = input.Description
 	event.PixKey = input.PixKey
-	event.EventDate = input.EventDate
-	event.HourStart = input.HourStart
-	event.HourEnd = input.HourEnd
+	event.PixName = input.PixName
+	event.PixCity = input.PixCity
+	event.StartsAt = startsAt
+	event.EndsAt = endsAt
+	event.Timezone = timezone
+	event.RSVPDeadline = rsvpDeadline
+	event.ReservationDeadline = reservationDeadline
 	event.Address = input.Address
 	event.Type = input.Type
 
-	if err := ctrl.DB.Save(&event).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível atualizar o evento"})
+	var changes EventChanges
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(&event).Error; err != nil {
+			return err
+		}
+		changes, err = sync.apply(tx, event.ID)
+		return err
+	})
+	switch {
+	case errors.Is(err, errGiftHasGivers):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "gift_has_givers"})
+		return
+	case errors.Is(err, errVersionConflict), errors.Is(err, errBelowReserved), errors.Is(err, errBelowCompanions):
+		respondEditError(c, err)
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível atualizar o evento, nenhuma alteração foi salva"})
 		return
 	}
+	for _, gift := range sync.deletedGifts {
+		ctrl.notifyGiftRemoved(event, gift)
+	}
 
-	c.JSON(http.StatusOK, gin.H{"event": event})
-}
-
-func (ctrl *Controller) DeleteEvent(c *gin.Context) {
-	eventID := c.Param("id")
-	userID := c.GetUint("userID")
-
-	var event models.Event
-	if err := ctrl.DB.First(&event, eventID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+	var updatedEvent models.Event
+	if err := ctrl.DB.Preload("Invited").Preload("Groups.Members").Preload("Gifts.Reservations").
+		First(&updatedEvent, event.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	if event.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas o criador pode deletar o evento"})
+	c.JSON(http.StatusOK, gin.H{"event": updatedEvent, "changes": changes})
+}
+
+func (ctrl *Controller) DeleteEvent(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleOwner)
+	if !ok {
 		return
 	}
 
@@ -275,16 +582,8 @@ func (ctrl *Controller) DeleteEvent(c *gin.Context) {
 }
 
 func (ctrl *Controller) AddInvited(c *gin.Context) {
-	eventID := c.Param("id")
-	userID := c.GetUint("userID")
-
-	var event models.Event
-	if err := ctrl.DB.First(&event, eventID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
-		return
-	}
-	if event.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas o criador pode adicionar convidados"})
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor)
+	if !ok {
 		return
 	}
 
@@ -294,13 +593,21 @@ func (ctrl *Controller) AddInvited(c *gin.Context) {
 		return
 	}
 
-	uuid := utils.GenerateCustomUUID()
-	inv := models.EventInvited{
-		EventID: event.ID,
-		UserID:  input.UserID,
-		Name:    input.Name,
-		UUID:    uuid,
+	if len(input.Members) > 0 {
+		group, err := createInviteGroup(ctrl.DB, event.ID, input)
+		if errors.Is(err, errNestedGroup) || errors.Is(err, errGroupMemberNoName) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao adicionar convidados"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"group": group})
+		return
 	}
+
+	inv := newInvited(event.ID, input)
 	if err := ctrl.DB.Create(&inv).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao adicionar convidado"})
 		return
@@ -309,22 +616,33 @@ func (ctrl *Controller) AddInvited(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"invited": inv})
 }
 
-func (ctrl *Controller) RemoveInvited(c *gin.Context) {
-	eventID := c.Param("id")
-	InviteUUID := c.Param("invite_id")
-	userID := c.GetUint("userID")
+// deleteInvite removes the guest along with their companions, releasing any gift they reserved.
+func deleteInvite(tx *gorm.DB, invite models.EventInvited) error {
+	if err := tx.Unscoped().Where("invite_uuid = ?", invite.UUID).Delete(&models.GiftReservation{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("event_invited_id = ?", invite.ID).Delete(&models.InvitedCompanion{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&invite).Error
+}
 
-	var event models.Event
-	if err := ctrl.DB.First(&event, eventID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+func (ctrl *Controller) RemoveInvited(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor)
+	if !ok {
 		return
 	}
-	if event.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas o criador pode remover convidados"})
+
+	invite, err := findEventInvite(ctrl.DB, event.ID, c.Param("invite_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Convidado não encontrado"})
 		return
 	}
 
-	if err := ctrl.DB.Delete(&models.EventInvited{}, InviteUUID).Error; err != nil {
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		return deleteInvite(tx, invite)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover convidado"})
 		return
 	}
@@ -333,16 +651,8 @@ func (ctrl *Controller) RemoveInvited(c *gin.Context) {
 }
 
 func (ctrl *Controller) AddGift(c *gin.Context) {
-	eventID := c.Param("id")
-	userID := c.GetUint("userID")
-
-	var event models.Event
-	if err := ctrl.DB.First(&event, eventID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
-		return
-	}
-	if event.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas o criador pode adicionar presentes"})
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor)
+	if !ok {
 		return
 	}
 
@@ -352,18 +662,10 @@ func (ctrl *Controller) AddGift(c *gin.Context) {
 		return
 	}
 
-	maxRes := uint(1)
-	if input.MaxReservations != "" {
-		if v, err := strconv.Atoi(input.MaxReservations); err == nil && v > 0 {
-			maxRes = uint(v)
-		}
-	}
-
-	gift := models.EventGift{
-		EventID:         event.ID,
-		Name:            input.Name,
-		Link:            input.Link,
-		MaxReservations: maxRes,
+	gift, err := buildGift(event.ID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	if err := ctrl.DB.Create(&gift).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao adicionar presente"})
@@ -373,25 +675,78 @@ func (ctrl *Controller) AddGift(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"gift": gift})
 }
 
-func (ctrl *Controller) RemoveGift(c *gin.Context) {
-	eventID := c.Param("id")
-	giftID := c.Param("gift_id")
-	userID := c.GetUint("userID")
+// deleteGift removes the gift and the reservations and contributions made for it.
+func deleteGift(tx *gorm.DB, gift models.EventGift) error {
+	if err := tx.Unscoped().Where("event_gift_id = ?", gift.ID).Delete(&models.GiftReservation{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("event_gift_id = ?", gift.ID).Delete(&models.GiftPledge{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&gift).Error
+}
 
-	var event models.Event
-	if err := ctrl.DB.First(&event, eventID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+// notifyGiftRemoved warns the guests who had reserved or contributed to a gift
+// that was deleted; the gift must have been loaded with Reservations and Pledges.
+func (ctrl *Controller) notifyGiftRemoved(event models.Event, gift models.EventGift) {
+	if ctrl.Notifier == nil || len(gift.Reservations)+len(gift.Pledges) == 0 {
+		return
+	}
+	var uuids []string
+	for _, r := range gift.Reservations {
+		uuids = append(uuids, r.InviteUUID)
+	}
+	for _, p := range gift.Pledges {
+		uuids = append(uuids, p.InviteUUID)
+	}
+	var guests []models.EventInvited
+	if err := ctrl.DB.Where("uuid IN ?", uuids).Find(&guests).Error; err == nil {
+		go ctrl.Notifier.GiftRemoved(event, gift, guests)
+	}
+}
+
+var errGiftHasGivers = errors.New("Este presente já foi reservado por convidados, use force=true para removê-lo mesmo assim")
+
+func (ctrl *Controller) RemoveGift(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor)
+	if !ok {
 		return
 	}
-	if event.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas o criador pode remover presentes"})
+
+	var gift models.EventGift
+	if err := ctrl.DB.Where("id = ? AND event_id = ?", c.Param("gift_id"), event.ID).First(&gift).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Presente não encontrado"})
 		return
 	}
 
-	if err := ctrl.DB.Delete(&models.EventGift{}, giftID).Error; err != nil {
+	// Reservations and contributions are counted with the gift locked, so one
+	// arriving meanwhile can't be deleted without force.
+	force := c.Query("force") == "true"
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockGift(tx, gift.ID); err != nil {
+			return err
+		}
+		if err := tx.Preload("Reservations").Preload("Pledges").First(&gift, gift.ID).Error; err != nil {
+			return err
+		}
+		if len(gift.Reservations)+len(gift.Pledges) > 0 && !force {
+			return errGiftHasGivers
+		}
+		return deleteGift(tx, gift)
+	})
+	if errors.Is(err, errGiftHasGivers) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        err.Error(),
+			"reservations": len(gift.Reservations),
+			"pledges":      len(gift.Pledges),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover presente"})
 		return
 	}
+	ctrl.notifyGiftRemoved(event, gift)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Presente removido com sucesso"})
 }
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+)
+
+type ChangeEventStatusInput struct {
+	Status models.EventStatus `json:"status" binding:"required"`
+	Reason string             `json:"reason"`
+}
+
+func rsvpClosedMessage(event models.Event) string {
+	switch event.Status {
+	case models.EventDraft:
+		return "Este convite ainda não foi publicado"
+	case models.EventCancelled:
+		return "Este evento foi cancelado"
+	case models.EventFinished:
+		return "Este evento já aconteceu"
+	}
+	if event.RSVPDeadline != nil && event.Status.AcceptsRSVP() {
+		return "O prazo para confirmar presença terminou em " + formatEventTime(*event.RSVPDeadline, event.Timezone)
+	}
+	return "As confirmações de presença estão encerradas"
+}
+
+// hideDraft answers 404 on the guest routes of a draft event, which guests can't
+// see until it's published.
+func hideDraft(c *gin.Context, event models.Event) bool {
+	if event.Status != models.EventDraft {
+		return false
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": rsvpClosedMessage(event)})
+	return true
+}
+
+func (ctrl *Controller) ChangeEventStatus(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	var input ChangeEventStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !event.Status.CanTransitionTo(input.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Não é possível mudar o evento de " + string(event.Status) + " para " + string(input.Status)})
+		return
+	}
+	if input.Status == models.EventPublished {
+		var user models.User
+		if err := ctrl.DB.Select("id", "verified_at").First(&user, c.GetUint("userID")).Error; err != nil || user.VerifiedAt == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Confirme seu e-mail antes de publicar o evento", "code": "email_not_verified"})
+			return
+		}
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if input.Status == models.EventCancelled && reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Informe o motivo do cancelamento"})
+		return
+	}
+
+	previous := event.Status
+	now := time.Now()
+	event.Status = input.Status
+	event.StatusChangedAt = &now
+	if input.Status == models.EventCancelled {
+		event.CancellationReason = reason
+	}
+
+	// Only applies if nobody changed the status since it was read, so two
+	// organizers can't both move the event out of the same state.
+	result := ctrl.DB.Model(&event).
+		Where("status = ?", previous).
+		Select("Status", "StatusChangedAt", "CancellationReason").
+		Updates(&event)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível alterar o status do evento"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "O status do evento foi alterado por outra pessoa, recarregue e tente novamente", "code": "status_conflict"})
+		return
+	}
+
+	if ctrl.Notifier != nil {
+		var guests []models.EventInvited
+		if err := ctrl.DB.Where("event_id = ?", event.ID).Find(&guests).Error; err == nil {
+			go ctrl.Notifier.EventStatusChanged(event, previous, guests)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event": event})
+}
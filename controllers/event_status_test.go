@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"gorm.io/gorm"
+)
+
+// countingNotifier records how many notifications each kind of change sent.
+type countingNotifier struct {
+	statusChanged int32
+	giftRemoved   int32
+}
+
+func (n *countingNotifier) EventStatusChanged(models.Event, models.EventStatus, []models.EventInvited) {
+	atomic.AddInt32(&n.statusChanged, 1)
+}
+
+func (n *countingNotifier) GiftRemoved(models.Event, models.EventGift, []models.EventInvited) {
+	atomic.AddInt32(&n.giftRemoved, 1)
+}
+
+func TestStatusChangeLosesToConcurrentChange(t *testing.T) {
+	ctrl := newTestController(t)
+	notifier := &countingNotifier{}
+	ctrl.Notifier = notifier
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+
+	// Another organizer cancels the event after this request read it as published.
+	var once sync.Once
+	ctrl.DB.Callback().Update().Before("gorm:begin_transaction").Register("test:concurrent_cancel", func(tx *gorm.DB) {
+		once.Do(func() {
+			ctrl.DB.Exec("UPDATE events SET status = ? WHERE id = ?", models.EventCancelled, event.ID)
+		})
+	})
+
+	r := gin.New()
+	r.POST("/events/:id/status", withUser(owner.ID), ctrl.ChangeEventStatus)
+	w, body := doJSON(r, http.MethodPost, fmt.Sprintf("/events/%d/status", event.ID), gin.H{"status": models.EventFinished}, nil)
+	if w.Code != http.StatusConflict || body["code"] != "status_conflict" {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	var saved models.Event
+	ctrl.DB.First(&saved, event.ID)
+	if saved.Status != models.EventCancelled {
+		t.Fatalf("cancelamento sobrescrito por %s", saved.Status)
+	}
+	if n := atomic.LoadInt32(&notifier.statusChanged); n != 0 {
+		t.Fatalf("%d notificação(ões) de uma mudança que não aconteceu", n)
+	}
+}
+
+func TestDraftEventHiddenFromInviteRoutes(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	ctrl.DB.Model(&event).Updates(map[string]interface{}{"status": models.EventDraft, "pix_key": "dona@example.com"})
+	invite := createInvite(t, ctrl.DB, event.ID, "Ana")
+
+	r := gin.New()
+	r.GET("/invites/:uuid/event", ctrl.GetEventByInvite)
+	r.GET("/invites/:uuid/pix", ctrl.GetInvitePix)
+	r.GET("/invites/:uuid/event.ics", ctrl.GetInviteICS)
+	paths := []string{"/invites/%s/event", "/invites/%s/pix", "/invites/%s/event.ics"}
+
+	for _, path := range paths {
+		w, _ := doJSON(r, http.MethodGet, fmt.Sprintf(path, invite.UUID), nil, nil)
+		if w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), event.Title) {
+			t.Fatalf("rascunho em %s: status %d: %s", path, w.Code, w.Body)
+		}
+	}
+
+	ctrl.DB.Model(&event).Update("status", models.EventPublished)
+	for _, path := range paths {
+		if w, _ := doJSON(r, http.MethodGet, fmt.Sprintf(path, invite.UUID), nil, nil); w.Code != http.StatusOK {
+			t.Fatalf("publicado em %s: status %d: %s", path, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+)
+
+// withUser stands in for the auth middleware in handler tests.
+func withUser(userID uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Next()
+	}
+}
+
+func eventPayload(gifts ...gin.H) gin.H {
+	return gin.H{
+		"type":       "not_defined",
+		"title":      "Chá da Ana",
+		"event_date": time.Now().AddDate(0, 1, 0).Format("2006-01-02"),
+		"hour_start": "15:00",
+		"address":    "Rua A, 1",
+		"gifts":      gifts,
+	}
+}
+
+func TestCreateEventRejectsInvalidFundGift(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	r := gin.New()
+	r.POST("/events", withUser(owner.ID), ctrl.CreateEvent)
+
+	w, body := doJSON(r, http.MethodPost, "/events", eventPayload(
+		gin.H{"name": "Fralda"},
+		gin.H{"name": "Fundo do berço", "kind": "fundo"},
+	), nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	fields, _ := body["fields"].(map[string]interface{})
+	if _, ok := fields["gifts[1].target_cents"]; !ok {
+		t.Fatalf("erro não aponta para o fundo: %v", body)
+	}
+
+	var events int64
+	ctrl.DB.Model(&models.Event{}).Count(&events)
+	if events != 0 {
+		t.Fatalf("%d evento(s) criados apesar do erro", events)
+	}
+}
+
+func TestCreateEventReportsEmptyFundProgress(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	r := gin.New()
+	r.POST("/events", withUser(owner.ID), ctrl.CreateEvent)
+
+	w, body := doJSON(r, http.MethodPost, "/events", eventPayload(
+		gin.H{"name": "Fundo do berço", "kind": "fundo", "target_cents": 50000},
+	), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	gifts := body["event"].(map[string]interface{})["Gifts"].([]interface{})
+	if pledged, ok := gifts[0].(map[string]interface{})["pledged_cents"]; !ok || pledged != float64(0) {
+		t.Fatalf("pledged_cents ausente ou diferente de zero: %v", gifts[0])
+	}
+}
+
+func TestUpdateEventKeepsDeadlinesLeftOut(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	r := gin.New()
+	r.POST("/events", withUser(owner.ID), ctrl.CreateEvent)
+	r.PUT("/events/:id", withUser(owner.ID), ctrl.UpdateEvent)
+
+	create := eventPayload()
+	deadline := time.Now().AddDate(0, 0, 7).Format("2006-01-02")
+	create["rsvp_deadline"] = deadline
+	create["reservation_deadline"] = deadline
+	w, body := doJSON(r, http.MethodPost, "/events", create, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	path := fmt.Sprintf("/events/%v", body["event"].(map[string]interface{})["ID"])
+
+	update := eventPayload()
+	update["title"] = "Chá da Ana e do Léo"
+	if w, _ := doJSON(r, http.MethodPut, path, update, nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var event models.Event
+	ctrl.DB.First(&event)
+	if event.RSVPDeadline == nil || event.ReservationDeadline == nil {
+		t.Fatalf("prazos apagados por um PUT sem eles: %v %v", event.RSVPDeadline, event.ReservationDeadline)
+	}
+
+	update["rsvp_deadline"] = ""
+	if w, _ := doJSON(r, http.MethodPut, path, update, nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	event = models.Event{}
+	ctrl.DB.First(&event)
+	if event.RSVPDeadline != nil || event.ReservationDeadline == nil {
+		t.Fatalf("prazos após limpar só o de confirmação: %v %v", event.RSVPDeadline, event.ReservationDeadline)
+	}
+}
+
+func TestGetEventIncludesTypeAndOwner(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	r := gin.New()
+	r.GET("/events/:id", withUser(owner.ID), ctrl.GetEvent)
+
+	w, body := doJSON(r, http.MethodGet, fmt.Sprintf("/events/%d", event.ID), nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	got := body["event"].(map[string]interface{})
+	if got["type"] != string(models.NotDefined) || got["user_id"] != float64(owner.ID) {
+		t.Fatalf("evento sem type/user_id: %v", got)
+	}
+}
+
+func TestCreateEventRejectsReversedHours(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	r := gin.New()
+	r.POST("/events", withUser(owner.ID), ctrl.CreateEvent)
+
+	payload := eventPayload()
+	payload["hour_end"] = "14:00"
+	w, body := doJSON(r, http.MethodPost, "/events", payload, nil)
+	if w.Code != http.StatusBadRequest || body["error"] != utils.ErrEndBeforeStart.Error() {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	payload["hour_start"], payload["hour_end"], payload["overnight"] = "20:00", "02:00", true
+	w, body = doJSON(r, http.MethodPost, "/events", payload, nil)
+	if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+		t.Fatalf("festa virando a noite: status %d: %s", w.Code, w.Body)
+	}
+	event := body["event"].(map[string]interface{})
+	startsAt, _ := time.Parse(time.RFC3339, event["starts_at"].(string))
+	endsAt, _ := time.Parse(time.RFC3339, event["ends_at"].(string))
+	if endsAt.Sub(startsAt) != 6*time.Hour {
+		t.Fatalf("duração %v, esperado 6h", endsAt.Sub(startsAt))
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+)
+
+const exportDateLayout = "02/01/2006 15:04"
+
+// formatEventTime shows t as the organizer sees it, in the event's timezone.
+func formatEventTime(t time.Time, timezone string) string {
+	if loc, err := utils.LoadTimezone(timezone); err == nil {
+		t = t.In(loc)
+	}
+	return t.Format(exportDateLayout)
+}
+
+type exportTable struct {
+	Filename string
+	Title    string
+	Header   []string
+	Widths   []float64
+	Rows     [][]string
+}
+
+// sendExport writes the table in the requested format as a file download.
+func sendExport(c *gin.Context, format string, table exportTable) {
+	var buf bytes.Buffer
+	var contentType string
+	var err error
+
+	switch format {
+	case "", "csv":
+		format = "csv"
+		contentType = "text/csv; charset=utf-8"
+		err = utils.WriteCSV(&buf, table.Header, table.Rows)
+	case "xlsx":
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		err = utils.WriteXLSX(&buf, "Planilha", table.Header, table.Rows)
+	case "pdf":
+		contentType = "application/pdf"
+		err = utils.WriteTablePDF(&buf, table.Title, table.Header, table.Widths, table.Rows)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato não suportado, use csv, xlsx ou pdf"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar o arquivo"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, table.Filename, format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+func rsvpStatus(invite models.EventInvited) string {
+	switch {
+	case invite.Accepted == nil:
+		return "Pendente"
+	case *invite.Accepted:
+		return "Confirmado"
+	default:
+		return "Recusado"
+	}
+}
+
+func (ctrl *Controller) ExportGuests(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleViewer, "Gifts", "Groups")
+	if !ok {
+		return
+	}
+
+	var invited []models.EventInvited
+	if err := ctrl.DB.Preload("Companions").Where("event_id = ?", event.ID).Order("name").Find(&invited).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar os convidados"})
+		return
+	}
+
+	uuids := make([]string, len(invited))
+	for i, inv := range invited {
+		uuids[i] = inv.UUID
+	}
+
+	var reservations []models.GiftReservation
+	var pledges []models.GiftPledge
+	if len(uuids) > 0 {
+		if err := ctrl.DB.Where("invite_uuid IN ?", uuids).Order("created_at").Find(&reservations).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar as reservas"})
+			return
+		}
+		if err := ctrl.DB.Where("invite_uuid IN ?", uuids).Order("created_at").Find(&pledges).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar as contribuições"})
+			return
+		}
+	}
+
+	giftNames := map[uint]string{}
+	for _, g := range event.Gifts {
+		giftNames[g.ID] = g.Name
+	}
+	groupNames := map[uint]string{}
+	for _, g := range event.Groups {
+		groupNames[g.ID] = g.Name
+	}
+	giftsByInvite := map[string][]string{}
+	for _, r := range reservations {
+		giftsByInvite[r.InviteUUID] = append(giftsByInvite[r.InviteUUID], giftNames[r.EventGiftID])
+	}
+	for _, p := range pledges {
+		giftsByInvite[p.InviteUUID] = append(giftsByInvite[p.InviteUUID],
+			fmt.Sprintf("%s (%s)", giftNames[p.EventGiftID], utils.FormatBRL(p.AmountCents)))
+	}
+
+	rows := make([][]string, 0, len(invited))
+	for _, inv := range invited {
+		respondedAt := ""
+		if inv.RespondedAt != nil {
+			respondedAt = formatEventTime(*inv.RespondedAt, event.Timezone)
+		}
+		group := ""
+		if inv.GroupID != nil {
+			group = groupNames[*inv.GroupID]
+		}
+		companions := make([]string, len(inv.Companions))
+		for i, comp := range inv.Companions {
+			companions[i] = comp.Name
+		}
+
+		rows = append(rows, []string{
+			inv.Name,
+			group,
+			rsvpStatus(inv),
+			respondedAt,
+			fmt.Sprint(inv.Adults),
+			fmt.Sprint(inv.Children),
+			strings.Join(companions, ", "),
+			inv.Whatsapp,
+			inv.Email,
+			inviteURL(c, inv.UUID),
+			strings.Join(giftsByInvite[inv.UUID], ", "),
+		})
+	}
+
+	sendExport(c, c.Query("format"), exportTable{
+		Filename: fmt.Sprintf("convidados-evento-%d", event.ID),
+		Title:    "Lista de convidados - " + event.Title,
+		Header: []string{
+			"Nome", "Grupo", "Status", "Respondido em", "Adultos", "Crianças",
+			"Acompanhantes", "WhatsApp", "E-mail", "Link do convite", "Presentes",
+		},
+		Widths: []float64{16, 10, 8, 10, 6, 6, 14, 10, 14, 22, 20},
+		Rows:   rows,
+	})
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExportGuestsUsesEventTimezone(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	ctrl.DB.Model(&event).Update("timezone", "Asia/Tokyo")
+	invite := createInvite(t, ctrl.DB, event.ID, "Ana")
+	respondedAt := time.Date(2026, 11, 21, 22, 30, 0, 0, time.UTC)
+	ctrl.DB.Model(&invite).Updates(map[string]interface{}{"accepted": true, "responded_at": respondedAt})
+
+	r := gin.New()
+	r.GET("/events/:id/export", withUser(owner.ID), ctrl.ExportGuests)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/events/%d/export", event.ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	// 22:30 UTC is already the next morning in Tokyo (UTC+9).
+	if csv := w.Body.String(); !strings.Contains(csv, ";22/11/2026 07:30;") {
+		t.Fatalf("data de resposta fora do fuso do evento:\n%s", csv)
+	}
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+)
+
+func TestRemoveGiftWithPledges(t *testing.T) {
+	ctrl := newTestController(t)
+	notifier := &countingNotifier{}
+	ctrl.Notifier = notifier
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	invite := createInvite(t, ctrl.DB, event.ID, "Bia")
+	fund := models.EventGift{EventID: event.ID, Name: "Fundo do berço", Kind: models.GiftFund, TargetCents: 50000, PledgedCents: 15000}
+	ctrl.DB.Create(&fund)
+	ctrl.DB.Create(&models.GiftPledge{EventGiftID: fund.ID, InviteUUID: invite.UUID, AmountCents: 15000})
+
+	r := gin.New()
+	r.DELETE("/events/:id/gifts/:gift_id", withUser(owner.ID), ctrl.RemoveGift)
+	path := fmt.Sprintf("/events/%d/gifts/%d", event.ID, fund.ID)
+
+	w, body := doJSON(r, http.MethodDelete, path, nil, nil)
+	if w.Code != http.StatusConflict || body["pledges"] != float64(1) {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	if w, _ := doJSON(r, http.MethodDelete, path+"?force=true", nil, nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var pledges int64
+	ctrl.DB.Unscoped().Model(&models.GiftPledge{}).Where("event_gift_id = ?", fund.ID).Count(&pledges)
+	if pledges != 0 {
+		t.Fatalf("%d contribuição(ões) órfã(s)", pledges)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&notifier.giftRemoved) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&notifier.giftRemoved) != 1 {
+		t.Fatal("quem contribuiu não foi avisado da remoção")
+	}
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+)
+
+func newInvited(eventID uint, input CreateInvitedInput) models.EventInvited {
+	return models.EventInvited{
+		EventID:       eventID,
+		UserID:        input.UserID,
+		Name:          input.Name,
+		Whatsapp:      utils.NormalizePhone(input.Whatsapp),
+		Email:         strings.ToLower(strings.TrimSpace(input.Email)),
+		UUID:          utils.GenerateCustomUUID(),
+		MaxCompanions: input.MaxCompanions,
+	}
+}
+
+var (
+	errNestedGroup       = errors.New("Grupos não podem conter outros grupos")
+	errGroupMemberNoName = errors.New("Todos os membros do grupo precisam de nome")
+)
+
+// createInviteGroup creates a household with its own invite link; each member
+// still gets an individual invite so they can RSVP and reserve on their own.
+func createInviteGroup(db *gorm.DB, eventID uint, input CreateInvitedInput) (models.InviteGroup, error) {
+	group := models.InviteGroup{
+		EventID: eventID,
+		Name:    input.Name,
+		UUID:    utils.GenerateCustomUUID(),
+	}
+	for _, member := range input.Members {
+		if len(member.Members) > 0 {
+			return group, errNestedGroup
+		}
+		if member.Name == "" {
+			return group, errGroupMemberNoName
+		}
+		group.Members = append(group.Members, newInvited(eventID, member))
+	}
+
+	err := db.Create(&group).Error
+	return group, err
+}
+
+func (ctrl *Controller) GetEventByGroup(c *gin.Context) {
+	groupUUID := c.Param("uuid")
+
+	var group models.InviteGroup
+	if err := ctrl.DB.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Members.Companions").
+		Where("uuid = ?", groupUUID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Convite não encontrado"})
+		return
+	}
+	if len(group.Members) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Este grupo não possui membros"})
+		return
+	}
+
+	// Gifts picked on the household page are reserved in the name of its first member.
+	ctrl.respondInviteEvent(c, group.Members[0], gin.H{"group": group})
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"gorm.io/gorm"
+)
+
+func TestInviteGroupFlow(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	gift := models.EventGift{EventID: event.ID, Name: "Banheira", Kind: models.GiftItem, MaxReservations: 1}
+	ctrl.DB.Create(&gift)
+
+	r := gin.New()
+	r.POST("/events/:id/invited", withUser(owner.ID), ctrl.AddInvited)
+	r.GET("/groups/:uuid/event", ctrl.GetEventByGroup)
+	r.POST("/invites/:uuid/respond", ctrl.RespondInvite)
+	r.POST("/gifts/reserve", ctrl.ReserveGift)
+	path := fmt.Sprintf("/events/%d/invited", event.ID)
+
+	household := gin.H{"name": "Família Souza", "members": []gin.H{{"name": "Ana"}, {"name": "Pedro"}}}
+	w, _ := doJSON(r, http.MethodPost, path, household, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var created struct {
+		Group models.InviteGroup `json:"group"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Group.UUID == "" || len(created.Group.Members) != 2 {
+		t.Fatalf("grupo criado: %s", w.Body)
+	}
+
+	// Each member answers on their own invite.
+	ana, pedro := created.Group.Members[0], created.Group.Members[1]
+	doJSON(r, http.MethodPost, "/invites/"+ana.UUID+"/respond", gin.H{"accepted": true}, nil)
+	doJSON(r, http.MethodPost, "/invites/"+pedro.UUID+"/respond", gin.H{"accepted": false}, nil)
+	if w, _ := doJSON(r, http.MethodPost, "/gifts/reserve", gin.H{"invite_uuid": ana.UUID, "event_gift_id": gift.ID}, nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	w, _ = doJSON(r, http.MethodGet, "/groups/"+created.Group.UUID+"/event", nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var page struct {
+		Group          models.InviteGroup       `json:"group"`
+		Invite         models.EventInvited      `json:"invite"`
+		MyReservations []models.GiftReservation `json:"my_reservations"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &page)
+	members := page.Group.Members
+	if len(members) != 2 || members[0].Accepted == nil || !*members[0].Accepted || members[1].Accepted == nil || *members[1].Accepted {
+		t.Fatalf("membros na página do grupo: %s", w.Body)
+	}
+	if page.Invite.UUID != ana.UUID || len(page.MyReservations) != 1 {
+		t.Fatalf("reservas do grupo deveriam estar no nome do primeiro membro: %s", w.Body)
+	}
+
+	if w, _ := doJSON(r, http.MethodGet, "/groups/nao-existe/event", nil, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("grupo inexistente, status %d", w.Code)
+	}
+}
+
+func TestAddInvitedGroupErrors(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	r := gin.New()
+	r.POST("/events/:id/invited", withUser(owner.ID), ctrl.AddInvited)
+	path := fmt.Sprintf("/events/%d/invited", event.ID)
+
+	cases := []struct {
+		name string
+		body gin.H
+	}{
+		{"membro sem nome", gin.H{"name": "Família", "members": []gin.H{{"name": "Ana"}, {"name": ""}}}},
+		{"grupo dentro de grupo", gin.H{"name": "Família", "members": []gin.H{{"name": "Tios", "members": []gin.H{{"name": "Rui"}}}}}},
+	}
+	for _, tc := range cases {
+		if w, _ := doJSON(r, http.MethodPost, path, tc.body, nil); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d: %s", tc.name, w.Code, w.Body)
+		}
+	}
+
+	// A storage failure is the server's fault, not a bad request.
+	ctrl.DB.Callback().Create().Before("gorm:create").Register("test:fail_groups", func(tx *gorm.DB) {
+		if tx.Statement.Table == "invite_groups" {
+			tx.AddError(errors.New("disco cheio"))
+		}
+	})
+	household := gin.H{"name": "Família Souza", "members": []gin.H{{"name": "Ana"}}}
+	if w, _ := doJSON(r, http.MethodPost, path, household, nil); w.Code != http.StatusInternalServerError {
+		t.Fatalf("falha do banco, status %d: %s", w.Code, w.Body)
+	}
+
+	var groups int64
+	ctrl.DB.Model(&models.InviteGroup{}).Where("event_id = ?", event.ID).Count(&groups)
+	if groups != 0 {
+		t.Fatalf("%d grupo(s) criado(s) com erro", groups)
+	}
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/internal/testutil"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/notifications"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newTestController(t *testing.T) *Controller {
+	t.Helper()
+	t.Setenv("APP_URL", "https://cha.example.com")
+	return &Controller{DB: testutil.OpenDB(t)}
+}
+
+func createUser(t *testing.T, db *gorm.DB, email string) models.User {
+	t.Helper()
+	now := time.Now()
+	user := models.User{NomeCompleto: "Teste", Email: email, Senha: "x", VerifiedAt: &now}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("criando usuário: %v", err)
+	}
+	return user
+}
+
+func createEvent(t *testing.T, db *gorm.DB, ownerID uint) models.Event {
+	t.Helper()
+	event := models.Event{
+		UserID:   ownerID,
+		Status:   models.EventPublished,
+		Type:     models.NotDefined,
+		Title:    "Chá da Ana",
+		Address:  "Rua A, 1",
+		StartsAt: time.Now().Add(30 * 24 * time.Hour),
+		Timezone: utils.DefaultTimezone,
+	}
+	if err := db.Create(&event).Error; err != nil {
+		t.Fatalf("criando evento: %v", err)
+	}
+	return event
+}
+
+func createInvite(t *testing.T, db *gorm.DB, eventID uint, name string) models.EventInvited {
+	t.Helper()
+	invite := newInvited(eventID, CreateInvitedInput{Name: name})
+	if err := db.Create(&invite).Error; err != nil {
+		t.Fatalf("criando convite: %v", err)
+	}
+	return invite
+}
+
+// doJSON sends body as JSON through the handler and decodes the JSON answer, if any.
+func doJSON(handler http.Handler, method, path string, body interface{}, header http.Header) (*httptest.ResponseRecorder, map[string]interface{}) {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var decoded map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &decoded)
+	return w, decoded
+}
+
+var mailTokenRegex = regexp.MustCompile(`token=([0-9a-f]+)`)
+
+// waitForMail waits for the n-th e-mail, since handlers send them in the background,
+// and returns it with the token of the link it carries.
+func waitForMail(t *testing.T, mailer *notifications.MemoryMailer, n int) (notifications.Message, string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if sent := mailer.Sent(); len(sent) >= n {
+			msg := sent[n-1]
+			token := ""
+			if m := mailTokenRegex.FindStringSubmatch(msg.Body); m != nil {
+				token = m[1]
+			}
+			return msg, token
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("esperava %d e-mail(s), enviados %d", n, len(mailer.Sent()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
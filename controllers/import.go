@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/mail"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+)
+
+const maxImportSize = 2 << 20
+
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	UUID   string `json:"uuid,omitempty"`
+	Group  string `json:"group,omitempty"`
+}
+
+func guestNameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// validateImportedContact turns a raw row into a guest, returning a message for the report when invalid.
+func validateImportedContact(contact utils.ImportedContact) (CreateInvitedInput, string) {
+	input := CreateInvitedInput{Name: strings.Join(strings.Fields(contact.Name), " ")}
+	if input.Name == "" {
+		return input, "Nome é obrigatório"
+	}
+
+	if contact.Whatsapp != "" {
+		input.Whatsapp = utils.NormalizePhone(contact.Whatsapp)
+		if len(input.Whatsapp) < 12 || len(input.Whatsapp) > 13 {
+			return input, "WhatsApp inválido"
+		}
+	}
+
+	if contact.Email != "" {
+		addr, err := mail.ParseAddress(contact.Email)
+		if err != nil {
+			return input, "E-mail inválido"
+		}
+		input.Email = strings.ToLower(addr.Address)
+	}
+
+	if contact.Companions != "" {
+		n, err := strconv.ParseUint(contact.Companions, 10, 32)
+		if err != nil {
+			return input, "Número de acompanhantes inválido"
+		}
+		input.MaxCompanions = uint(n)
+	}
+
+	return input, ""
+}
+
+func (ctrl *Controller) ImportInvited(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Envie o arquivo no campo \"file\""})
+		return
+	}
+	if fileHeader.Size > maxImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Arquivo muito grande (máximo 2 MB)"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não foi possível ler o arquivo"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não foi possível ler o arquivo"})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+
+	var contacts []utils.ImportedContact
+	switch format {
+	case "csv":
+		contacts, err = utils.ParseContactsCSV(data)
+	case "vcf", "vcard":
+		contacts, err = utils.ParseVCards(data)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato não suportado, use CSV ou vCard"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var report []ImportRowResult
+	imported := 0
+	err = ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		report = nil
+		imported = 0
+
+		var existing []models.EventInvited
+		if err := tx.Where("event_id = ?", event.ID).Find(&existing).Error; err != nil {
+			return err
+		}
+		seenNames := map[string]bool{}
+		seenPhones := map[string]bool{}
+		for _, inv := range existing {
+			seenNames[guestNameKey(inv.Name)] = true
+			if inv.Whatsapp != "" {
+				seenPhones[inv.Whatsapp] = true
+			}
+		}
+
+		var groups []models.InviteGroup
+		if err := tx.Where("event_id = ?", event.ID).Find(&groups).Error; err != nil {
+			return err
+		}
+		groupsByName := map[string]*models.InviteGroup{}
+		for i := range groups {
+			groupsByName[guestNameKey(groups[i].Name)] = &groups[i]
+		}
+
+		for _, contact := range contacts {
+			result := ImportRowResult{Row: contact.Row, Name: contact.Name, Group: contact.Group}
+
+			input, invalid := validateImportedContact(contact)
+			switch {
+			case invalid != "":
+				result.Status = "invalid"
+				result.Error = invalid
+			case seenNames[guestNameKey(input.Name)]:
+				result.Status = "duplicate"
+				result.Error = "Já existe um convidado com este nome"
+			case input.Whatsapp != "" && seenPhones[input.Whatsapp]:
+				result.Status = "duplicate"
+				result.Error = "Já existe um convidado com este WhatsApp"
+			}
+			if result.Status != "" {
+				report = append(report, result)
+				continue
+			}
+
+			invited := newInvited(event.ID, input)
+			if contact.Group != "" {
+				group, ok := groupsByName[guestNameKey(contact.Group)]
+				if !ok {
+					group = &models.InviteGroup{
+						EventID: event.ID,
+						Name:    strings.TrimSpace(contact.Group),
+						UUID:    utils.GenerateCustomUUID(),
+					}
+					if err := tx.Create(group).Error; err != nil {
+						return err
+					}
+					groupsByName[guestNameKey(contact.Group)] = group
+				}
+				invited.GroupID = &group.ID
+			}
+			if err := tx.Create(&invited).Error; err != nil {
+				return err
+			}
+
+			seenNames[guestNameKey(input.Name)] = true
+			if input.Whatsapp != "" {
+				seenPhones[input.Whatsapp] = true
+			}
+			result.Status = "created"
+			result.Name = invited.Name
+			result.UUID = invited.UUID
+			report = append(report, result)
+			imported++
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao importar convidados, nenhuma alteração foi salva"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": imported, "skipped": len(report) - imported, "rows": report})
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+)
+
+func TestImportInvitedReportsPartialFailures(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	createInvite(t, ctrl.DB, event.ID, "Carla Lima")
+
+	csv := "nome;telefone;email;família\n" +
+		"Ana Souza;(11) 98765-4321;;Souza\n" +
+		"Pedro Souza;;pedro@example.com;souza\n" +
+		";11912345678;;\n" +
+		"Bia;123;;\n" +
+		"Dani;;não-é-email;\n" +
+		"carla  lima;;;\n" +
+		"Ana Paula;11 98765-4321;;\n"
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, _ := form.CreateFormFile("file", "convidados.csv")
+	part.Write([]byte(csv))
+	form.Close()
+
+	r := gin.New()
+	r.POST("/events/:id/invited/import", withUser(owner.ID), ctrl.ImportInvited)
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/events/%d/invited/import", event.ID), &buf)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	var body struct {
+		Imported int               `json:"imported"`
+		Skipped  int               `json:"skipped"`
+		Rows     []ImportRowResult `json:"rows"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Imported != 2 || body.Skipped != 5 {
+		t.Fatalf("importados %d, ignorados %d: %s", body.Imported, body.Skipped, w.Body)
+	}
+	want := []struct {
+		row    int
+		status string
+		error  string
+	}{
+		{2, "created", ""},
+		{3, "created", ""},
+		{4, "invalid", "Nome é obrigatório"},
+		{5, "invalid", "WhatsApp inválido"},
+		{6, "invalid", "E-mail inválido"},
+		{7, "duplicate", "Já existe um convidado com este nome"},
+		{8, "duplicate", "Já existe um convidado com este WhatsApp"},
+	}
+	if len(body.Rows) != len(want) {
+		t.Fatalf("%d linha(s) no relatório: %s", len(body.Rows), w.Body)
+	}
+	for i, row := range body.Rows {
+		if row.Row != want[i].row || row.Status != want[i].status || row.Error != want[i].error {
+			t.Errorf("linha %+v; esperava %+v", row, want[i])
+		}
+	}
+
+	var guests int64
+	ctrl.DB.Model(&models.EventInvited{}).Where("event_id = ?", event.ID).Count(&guests)
+	if guests != 3 {
+		t.Fatalf("%d convidado(s) no evento; esperava 3", guests)
+	}
+	var groups []models.InviteGroup
+	ctrl.DB.Preload("Members").Where("event_id = ?", event.ID).Find(&groups)
+	if len(groups) != 1 || len(groups[0].Members) != 2 {
+		t.Fatalf("grupos %+v; esperava a família Souza com 2 membros", groups)
+	}
+}
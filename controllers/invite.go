@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,3 +14,33 @@ type InvitePageController struct{}
 func (ctrl *InvitePageController) ServePage(c *gin.Context) {
 	c.HTML(http.StatusOK, "invite.html", gin.H{})
 }
+
+// publicBaseURL is the address guests use to open invites, taken from APP_URL
+// or, when unset, from the current request.
+func publicBaseURL(c *gin.Context) string {
+	if base := os.Getenv("APP_URL"); base != "" {
+		return strings.TrimSuffix(base, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+var errAppURLMissing = errors.New("APP_URL não configurada")
+
+// emailBaseURL is the address used in links sent by e-mail. Unlike publicBaseURL
+// it never trusts the request: a forged Host header would otherwise send the
+// token in a genuine e-mail to someone else's server.
+func emailBaseURL() (string, error) {
+	base := os.Getenv("APP_URL")
+	if base == "" {
+		return "", errAppURLMissing
+	}
+	return strings.TrimSuffix(base, "/"), nil
+}
+
+func inviteURL(c *gin.Context, inviteUUID string) string {
+	return publicBaseURL(c) + "/invite?uuid=" + inviteUUID
+}
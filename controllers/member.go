@@ -0,0 +1,424 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/notifications"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InviteMemberInput struct {
+	Email string           `json:"email" binding:"required,email"`
+	Role  models.EventRole `json:"role" binding:"required"`
+}
+
+type AcceptMemberInviteInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type UpdateMemberInput struct {
+	Role models.EventRole `json:"role" binding:"required"`
+}
+
+type TransferOwnershipInput struct {
+	MemberID uint `json:"member_id" binding:"required"`
+}
+
+const memberInviteTTL = 7 * 24 * time.Hour
+
+var (
+	errMemberInviteInvalid = errors.New("Convite inválido ou expirado, peça um novo ao dono do evento")
+	errMemberNeedsAccount  = errors.New("Crie sua conta com o e-mail convidado e abra o link novamente")
+)
+
+// eventRole resolves what the user can do on the event; ok is false for non-members.
+func eventRole(db *gorm.DB, event models.Event, userID uint) (models.EventRole, bool) {
+	if event.UserID == userID {
+		return models.RoleOwner, true
+	}
+	var member models.EventMember
+	if err := db.Where("event_id = ? AND user_id = ? AND accepted_at IS NOT NULL", event.ID, userID).First(&member).Error; err != nil {
+		return "", false
+	}
+	return member.Role, true
+}
+
+// authorizeEvent loads the event from the :id param and checks the logged user has
+// at least the required role, writing the error response when not.
+func (ctrl *Controller) authorizeEvent(c *gin.Context, required models.EventRole, preloads ...string) (models.Event, bool) {
+	userID := c.GetUint("userID")
+
+	var event models.Event
+	eventID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+		return event, false
+	}
+	if err := ctrl.DB.Where("id = ?", eventID).First(&event).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+		return event, false
+	}
+
+	// Non-members get the same answer as a missing event so IDs can't be probed.
+	role, ok := eventRole(ctrl.DB, event, userID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+		return event, false
+	}
+	if !role.Allows(required) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sua função neste evento não permite esta ação"})
+		return event, false
+	}
+
+	if len(preloads) > 0 {
+		query := ctrl.DB
+		for _, p := range preloads {
+			query = query.Preload(p)
+		}
+		if err := query.Where("id = ?", event.ID).First(&event).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+			return event, false
+		}
+	}
+	return event, true
+}
+
+// memberEventIDs selects the events the user co-hosts, for use in "id IN ?" filters.
+func memberEventIDs(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&models.EventMember{}).Select("event_id").Where("user_id = ? AND accepted_at IS NOT NULL", userID)
+}
+
+func (ctrl *Controller) ListMembers(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	var owner models.User
+	if err := ctrl.DB.First(&owner, event.UserID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar o dono do evento"})
+		return
+	}
+
+	var members []models.EventMember
+	if err := ctrl.DB.Where("event_id = ?", event.ID).Order("id").Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar os organizadores"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"owner":   gin.H{"user_id": owner.ID, "email": owner.Email, "nome_completo": owner.NomeCompleto, "role": models.RoleOwner},
+		"members": members,
+	})
+}
+
+func (ctrl *Controller) InviteMember(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	var input InviteMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !input.Role.Valid() || input.Role == models.RoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Função inválida, use editor ou viewer"})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	var owner models.User
+	if err := ctrl.DB.First(&owner, event.UserID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar o dono do evento"})
+		return
+	}
+	if strings.EqualFold(owner.Email, email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Este usuário já é o dono do evento"})
+		return
+	}
+
+	baseURL, err := emailBaseURL()
+	if err != nil {
+		log.Printf("convite de organizador no evento %d: %v", event.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível enviar o convite agora, tente mais tarde"})
+		return
+	}
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível convidar o organizador"})
+		return
+	}
+	tokenHash := utils.HashToken(token)
+	expiresAt := time.Now().Add(memberInviteTTL)
+
+	// Even e-mails that already have an account stay pending until the link is
+	// opened, which is what proves the address belongs to them.
+	member := models.EventMember{
+		EventID:         event.ID,
+		Email:           email,
+		Role:            input.Role,
+		InvitedBy:       c.GetUint("userID"),
+		TokenHash:       &tokenHash,
+		InviteExpiresAt: &expiresAt,
+	}
+	err = ctrl.DB.Create(&member).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		member, err = renewExpiredMemberInvite(ctrl.DB, member)
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Este e-mail já foi convidado para o evento"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível convidar o organizador"})
+		return
+	}
+
+	ctrl.sendMemberInvite(baseURL, event, member, token)
+
+	c.JSON(http.StatusOK, gin.H{"member": member})
+}
+
+// renewExpiredMemberInvite reuses the row of an expired, never accepted invitation
+// for the same e-mail, which would otherwise hold the (event, e-mail) index forever.
+// It returns gorm.ErrDuplicatedKey when the existing invitation is still valid.
+func renewExpiredMemberInvite(db *gorm.DB, invite models.EventMember) (models.EventMember, error) {
+	var existing models.EventMember
+	if err := db.Where("event_id = ? AND email = ?", invite.EventID, invite.Email).First(&existing).Error; err != nil {
+		return invite, err
+	}
+
+	result := db.Model(&existing).
+		Where("accepted_at IS NULL AND user_id IS NULL AND invite_expires_at <= ?", time.Now()).
+		Updates(map[string]interface{}{
+			"role":              invite.Role,
+			"invited_by":        invite.InvitedBy,
+			"token_hash":        invite.TokenHash,
+			"invite_expires_at": invite.InviteExpiresAt,
+		})
+	if result.Error != nil {
+		return invite, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return invite, gorm.ErrDuplicatedKey
+	}
+	return existing, nil
+}
+
+func (ctrl *Controller) sendMemberInvite(baseURL string, event models.Event, member models.EventMember, token string) {
+	if ctrl.Mailer == nil {
+		return
+	}
+	var inviter models.User
+	ctrl.DB.Select("nome_completo").First(&inviter, member.InvitedBy)
+
+	role := "visualizar"
+	if member.Role == models.RoleEditor {
+		role = "editar"
+	}
+	msg := notifications.Message{
+		To:      member.Email,
+		Subject: "Convite para organizar " + event.Title,
+		Body: inviter.NomeCompleto + " convidou você para " + role + " o evento \"" + event.Title + "\".\n\n" +
+			"Para aceitar, entre com uma conta cadastrada neste e-mail e acesse o link abaixo em até 7 dias:\n" +
+			baseURL + "/members/accept?token=" + token + "\n\n" +
+			"Se você não conhece o organizador, ignore este e-mail.",
+	}
+	go func() {
+		if err := ctrl.Mailer.Send(msg); err != nil {
+			log.Printf("convite de organizador %d: %v", member.ID, err)
+		}
+	}()
+}
+
+// pendingMemberInvite finds the invitation behind an e-mailed link.
+func pendingMemberInvite(db *gorm.DB, token string) (models.EventMember, error) {
+	var member models.EventMember
+	err := db.Where("token_hash = ? AND accepted_at IS NULL AND invite_expires_at > ?", utils.HashToken(token), time.Now()).
+		First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return member, errMemberInviteInvalid
+	}
+	return member, err
+}
+
+// ServeMemberInvitePage is opened from the invitation e-mail. Accepting takes a
+// click, so mail scanners following the link don't accept it on their own.
+func (ctrl *Controller) ServeMemberInvitePage(c *gin.Context) {
+	member, err := pendingMemberInvite(ctrl.DB, c.Query("token"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "member_invite.html", gin.H{"error": errMemberInviteInvalid.Error()})
+		return
+	}
+	var event models.Event
+	if err := ctrl.DB.First(&event, member.EventID).Error; err != nil {
+		c.HTML(http.StatusBadRequest, "member_invite.html", gin.H{"error": errMemberInviteInvalid.Error()})
+		return
+	}
+	c.HTML(http.StatusOK, "member_invite.html", gin.H{"event": event, "member": member})
+}
+
+// AcceptMemberInvite consumes the invitation link and links it to the account
+// registered with the invited e-mail. Access starts once that account is verified.
+func (ctrl *Controller) AcceptMemberInvite(c *gin.Context) {
+	var input AcceptMemberInviteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var member models.EventMember
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if member, err = pendingMemberInvite(tx.Clauses(clause.Locking{Strength: "UPDATE"}), input.Token); err != nil {
+			return err
+		}
+		var user models.User
+		if err := tx.Where("email = ?", member.Email).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errMemberNeedsAccount
+			}
+			return err
+		}
+
+		// Anyone can sign up with the invited e-mail, so an unconfirmed account only
+		// claims the invite; VerifyEmail grants the access.
+		updates := map[string]interface{}{"user_id": user.ID, "token_hash": nil}
+		member.UserID = &user.ID
+		if user.VerifiedAt != nil {
+			now := time.Now()
+			member.AcceptedAt = &now
+			updates["accepted_at"] = now
+		}
+		return tx.Model(&member).Updates(updates).Error
+	})
+	switch {
+	case errors.Is(err, errMemberInviteInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, errMemberNeedsAccount):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "account_required", "email": member.Email})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível aceitar o convite"})
+	case member.AcceptedAt == nil:
+		c.JSON(http.StatusAccepted, gin.H{"message": "Convite aceito! Confirme seu e-mail pelo link que enviamos para liberar o acesso ao evento.", "code": "verification_required", "member": member})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Convite aceito! O evento já aparece na sua conta.", "member": member})
+	}
+}
+
+func (ctrl *Controller) UpdateMember(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	var input UpdateMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !input.Role.Valid() || input.Role == models.RoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Função inválida, use editor ou viewer"})
+		return
+	}
+
+	var member models.EventMember
+	if err := ctrl.DB.Where("id = ? AND event_id = ?", c.Param("member_id"), event.ID).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organizador não encontrado"})
+		return
+	}
+
+	if err := ctrl.DB.Model(&member).Update("role", input.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível atualizar o organizador"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"member": member})
+}
+
+func (ctrl *Controller) RemoveMember(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleViewer)
+	if !ok {
+		return
+	}
+	userID := c.GetUint("userID")
+
+	var member models.EventMember
+	if err := ctrl.DB.Where("id = ? AND event_id = ?", c.Param("member_id"), event.ID).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organizador não encontrado"})
+		return
+	}
+
+	leaving := member.UserID != nil && *member.UserID == userID
+	if event.UserID != userID && !leaving {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas o dono pode remover outros organizadores"})
+		return
+	}
+
+	if err := ctrl.DB.Unscoped().Delete(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível remover o organizador"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Organizador removido com sucesso"})
+}
+
+func (ctrl *Controller) TransferOwnership(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	var input TransferOwnershipInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var member models.EventMember
+	if err := ctrl.DB.Where("id = ? AND event_id = ?", input.MemberID, event.ID).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organizador não encontrado"})
+		return
+	}
+	if member.UserID == nil || member.AcceptedAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O organizador precisa aceitar o convite para receber o evento"})
+		return
+	}
+
+	var previousOwner models.User
+	if err := ctrl.DB.First(&previousOwner, event.UserID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar o dono do evento"})
+		return
+	}
+
+	// The new owner leaves the members list and the previous owner stays on as editor.
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&event).Update("user_id", *member.UserID).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		return tx.Unscoped().Model(&member).Updates(map[string]interface{}{
+			"user_id":     previousOwner.ID,
+			"email":       strings.ToLower(previousOwner.Email),
+			"role":        models.RoleEditor,
+			"accepted_at": &now,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível transferir o evento"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event": event})
+}
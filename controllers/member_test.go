@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/notifications"
+)
+
+func TestCoHostMustAcceptEmailedInvite(t *testing.T) {
+	ctrl := newTestController(t)
+	mailer := &notifications.MemoryMailer{}
+	ctrl.Mailer = mailer
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+
+	r := gin.New()
+	r.POST("/register", func(c *gin.Context) { Register(c, ctrl.DB, mailer) })
+	r.POST("/members/accept", ctrl.AcceptMemberInvite)
+	r.POST("/as/owner/events/:id/members", withUser(owner.ID), ctrl.InviteMember)
+	eventPath := fmt.Sprintf("/events/%d", event.ID)
+
+	w, body := doJSON(r, http.MethodPost, "/as/owner"+eventPath+"/members", gin.H{"email": "Co@Example.com", "role": "editor"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if member := body["member"].(map[string]interface{}); member["accepted_at"] != nil || member["user_id"] != nil {
+		t.Fatalf("convite aceito sem o convidado abrir o link: %v", member)
+	}
+	msg, token := waitForMail(t, mailer, 1)
+	if msg.To != "co@example.com" || token == "" {
+		t.Fatalf("convite enviado para %q, token %q", msg.To, token)
+	}
+
+	// No account with the invited e-mail yet: the link stays valid for later.
+	w, body = doJSON(r, http.MethodPost, "/members/accept", gin.H{"token": token}, nil)
+	if w.Code != http.StatusConflict || body["code"] != "account_required" {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	// Signing up with the invited e-mail alone doesn't grant access.
+	w, _ = doJSON(r, http.MethodPost, "/register", gin.H{"nome_completo": "Co", "email": "co@example.com", "senha": "segredo"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cadastro: status %d: %s", w.Code, w.Body)
+	}
+	var coHost models.User
+	ctrl.DB.Where("email = ?", "co@example.com").First(&coHost)
+	r.GET("/as/cohost/events/:id", withUser(coHost.ID), ctrl.GetEvent)
+	if w, _ := doJSON(r, http.MethodGet, "/as/cohost"+eventPath, nil, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("convite pendente deu acesso ao evento: status %d", w.Code)
+	}
+
+	// Accepting with an unconfirmed account waits for the e-mail confirmation.
+	w, body = doJSON(r, http.MethodPost, "/members/accept", gin.H{"token": token}, nil)
+	if w.Code != http.StatusAccepted || body["code"] != "verification_required" {
+		t.Fatalf("aceite: status %d: %s", w.Code, w.Body)
+	}
+	if w, _ := doJSON(r, http.MethodGet, "/as/cohost"+eventPath, nil, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("conta não confirmada com acesso ao evento: status %d", w.Code)
+	}
+
+	r.LoadHTMLGlob("../templates/*")
+	r.GET("/email/verify", func(c *gin.Context) { VerifyEmail(c, ctrl.DB) })
+	_, verifyToken := waitForMail(t, mailer, 2)
+	if w, _ := doJSON(r, http.MethodGet, "/email/verify?token="+verifyToken, nil, nil); w.Code != http.StatusOK {
+		t.Fatalf("confirmação: status %d: %s", w.Code, w.Body)
+	}
+	if w, _ := doJSON(r, http.MethodGet, "/as/cohost"+eventPath, nil, nil); w.Code != http.StatusOK {
+		t.Fatalf("organizador aceito sem acesso: status %d", w.Code)
+	}
+
+	if w, _ := doJSON(r, http.MethodPost, "/members/accept", gin.H{"token": token}, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("link reutilizado: status %d", w.Code)
+	}
+}
+
+func TestInviteMemberDoesNotAutoAcceptExistingUser(t *testing.T) {
+	ctrl := newTestController(t)
+	mailer := &notifications.MemoryMailer{}
+	ctrl.Mailer = mailer
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	existing := createUser(t, ctrl.DB, "co@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+
+	r := gin.New()
+	r.POST("/events/:id/members", withUser(owner.ID), ctrl.InviteMember)
+	r.GET("/as/existing/events/:id", withUser(existing.ID), ctrl.GetEvent)
+
+	w, _ := doJSON(r, http.MethodPost, fmt.Sprintf("/events/%d/members", event.ID), gin.H{"email": "co@example.com", "role": "viewer"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if w, _ := doJSON(r, http.MethodGet, fmt.Sprintf("/as/existing/events/%d", event.ID), nil, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("usuário existente ganhou acesso sem aceitar: status %d", w.Code)
+	}
+	if msg, _ := waitForMail(t, mailer, 1); !strings.Contains(msg.Body, "https://cha.example.com/members/accept?token=") {
+		t.Fatalf("e-mail sem link de aceite:\n%s", msg.Body)
+	}
+}
+
+func TestExpiredMemberInviteCanBeResent(t *testing.T) {
+	ctrl := newTestController(t)
+	mailer := &notifications.MemoryMailer{}
+	ctrl.Mailer = mailer
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	coHost := createUser(t, ctrl.DB, "co@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+
+	r := gin.New()
+	r.POST("/events/:id/members", withUser(owner.ID), ctrl.InviteMember)
+	r.POST("/members/accept", ctrl.AcceptMemberInvite)
+	path := fmt.Sprintf("/events/%d/members", event.ID)
+
+	if w, _ := doJSON(r, http.MethodPost, path, gin.H{"email": "co@example.com", "role": "viewer"}, nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	_, expiredToken := waitForMail(t, mailer, 1)
+	if w, _ := doJSON(r, http.MethodPost, path, gin.H{"email": "co@example.com", "role": "editor"}, nil); w.Code != http.StatusConflict {
+		t.Fatalf("convite válido reenviado: status %d", w.Code)
+	}
+
+	ctrl.DB.Model(&models.EventMember{}).Where("event_id = ?", event.ID).Update("invite_expires_at", time.Now().Add(-time.Minute))
+	w, body := doJSON(r, http.MethodPost, path, gin.H{"email": "co@example.com", "role": "editor"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("convite expirado não reenviado: status %d: %s", w.Code, w.Body)
+	}
+	if member := body["member"].(map[string]interface{}); member["role"] != "editor" {
+		t.Fatalf("convite renovado: %v", member)
+	}
+	_, token := waitForMail(t, mailer, 2)
+
+	if w, _ := doJSON(r, http.MethodPost, "/members/accept", gin.H{"token": expiredToken}, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("link antigo aceito: status %d", w.Code)
+	}
+	if w, _ := doJSON(r, http.MethodPost, "/members/accept", gin.H{"token": token}, nil); w.Code != http.StatusOK {
+		t.Fatalf("aceite: status %d: %s", w.Code, w.Body)
+	}
+	if role, ok := eventRole(ctrl.DB, event, coHost.ID); !ok || role != models.RoleEditor {
+		t.Fatalf("função %q, membro %v", role, ok)
+	}
+}
+
+func TestInviteMemberRequiresAppURL(t *testing.T) {
+	ctrl := newTestController(t)
+	mailer := &notifications.MemoryMailer{}
+	ctrl.Mailer = mailer
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	t.Setenv("APP_URL", "")
+
+	r := gin.New()
+	r.POST("/events/:id/members", withUser(owner.ID), ctrl.InviteMember)
+	w, _ := doJSON(r, http.MethodPost, fmt.Sprintf("/events/%d/members", event.ID), gin.H{"email": "co@example.com", "role": "viewer"}, nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var members int64
+	ctrl.DB.Model(&models.EventMember{}).Count(&members)
+	if members != 0 {
+		t.Fatalf("%d convite(s) criados sem link", members)
+	}
+}
@@ -0,0 +1,410 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// validateNestedInputs checks the guests and gifts sent along with an event and
+// returns the problems keyed by their path in the payload, e.g. "gifts[3].name".
+func validateNestedInputs(input CreateEventInput) map[string]string {
+	fields := map[string]string{}
+
+	for i, inv := range input.Invited {
+		path := fmt.Sprintf("invited[%d]", i)
+		validateInvitedInput(path, inv, fields)
+		for j, member := range inv.Members {
+			memberPath := fmt.Sprintf("%s.members[%d]", path, j)
+			if len(member.Members) > 0 {
+				fields[memberPath+".members"] = "Grupos não podem conter outros grupos"
+			}
+			validateInvitedInput(memberPath, member, fields)
+		}
+	}
+
+	for i, gift := range input.Gifts {
+		path := fmt.Sprintf("gifts[%d]", i)
+		if strings.TrimSpace(gift.Name) == "" {
+			fields[path+".name"] = "Nome é obrigatório"
+		}
+		_, err := buildGift(0, gift)
+		switch {
+		case errors.Is(err, errInvalidLimit):
+			fields[path+".max_reservations"] = err.Error()
+		case errors.Is(err, errInvalidTarget):
+			fields[path+".target_cents"] = err.Error()
+		case err != nil:
+			fields[path+".kind"] = err.Error()
+		}
+	}
+
+	return fields
+}
+
+func validateInvitedInput(path string, input CreateInvitedInput, fields map[string]string) {
+	if strings.TrimSpace(input.Name) == "" {
+		fields[path+".name"] = "Nome é obrigatório"
+	}
+	if input.Whatsapp != "" {
+		if phone := utils.NormalizePhone(input.Whatsapp); len(phone) < 12 || len(phone) > 13 {
+			fields[path+".whatsapp"] = "WhatsApp inválido"
+		}
+	}
+	if input.Email != "" {
+		if _, err := mail.ParseAddress(input.Email); err != nil {
+			fields[path+".email"] = "E-mail inválido"
+		}
+	}
+}
+
+// NestedChanges counts what an event update did to one of its collections.
+type NestedChanges struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+type EventChanges struct {
+	Invited NestedChanges `json:"invited"`
+	Gifts   NestedChanges `json:"gifts"`
+}
+
+type rowUpdate struct {
+	ID      uint
+	Version uint
+	Changes map[string]interface{}
+}
+
+type groupMember struct {
+	GroupID uint
+	Input   CreateInvitedInput
+}
+
+// nestedSync is the reconciliation between the collections sent on an event
+// update and the ones stored, planned up front so every problem is reported
+// before anything is written. The counts it relies on are checked again under
+// lock by apply.
+type nestedSync struct {
+	newInvited     []CreateInvitedInput
+	updatedInvited []rowUpdate
+	deletedInvited []models.EventInvited
+
+	newMembers    []groupMember
+	renamedGroups []models.InviteGroup
+	deletedGroups []models.InviteGroup
+
+	newGifts     []models.EventGift
+	updatedGifts []rowUpdate
+	deletedGifts []models.EventGift
+	force        bool
+}
+
+const errVersionRequired = "Informe a versão lida deste item"
+
+// planInvitedSync diffs the guests of the event, loaded with Invited.Companions
+// and Groups, against the payload. Households are matched by their group ID and
+// their members by their own ID; members may also be sent as individual guests.
+// A nil slice means the client didn't send the collection and nothing changes.
+func (s *nestedSync) planInvitedSync(event models.Event, inputs []CreateInvitedInput, fields map[string]string) {
+	if inputs == nil {
+		return
+	}
+
+	existing := map[uint]models.EventInvited{}
+	for _, inv := range event.Invited {
+		existing[inv.ID] = inv
+	}
+	groups := map[uint]models.InviteGroup{}
+	for _, group := range event.Groups {
+		groups[group.ID] = group
+	}
+
+	seen := map[uint]bool{}
+	keptGroups := map[uint]bool{}
+	diff := func(path string, input CreateInvitedInput, groupID *uint) {
+		current, ok := existing[input.ID]
+		if !ok || seen[input.ID] || groupID != nil && (current.GroupID == nil || *current.GroupID != *groupID) {
+			fields[path+".id"] = "Convidado não encontrado neste evento"
+			return
+		}
+		seen[input.ID] = true
+		if current.GroupID != nil {
+			keptGroups[*current.GroupID] = true
+		}
+		if len(input.Members) > 0 {
+			fields[path+".members"] = "Convidados existentes não podem virar grupos"
+			return
+		}
+		if input.Version == 0 {
+			fields[path+".version"] = errVersionRequired
+			return
+		}
+
+		changes := map[string]interface{}{}
+		if name := strings.TrimSpace(input.Name); name != current.Name {
+			changes["name"] = name
+		}
+		if phone := utils.NormalizePhone(input.Whatsapp); phone != current.Whatsapp {
+			changes["whatsapp"] = phone
+		}
+		if email := strings.ToLower(strings.TrimSpace(input.Email)); email != current.Email {
+			changes["email"] = email
+		}
+		if input.MaxCompanions != current.MaxCompanions {
+			if int(input.MaxCompanions) < companionsNeeded(current) {
+				fields[path+".max_companions"] = errBelowCompanions.Error()
+				return
+			}
+			changes["max_companions"] = input.MaxCompanions
+		}
+		if len(changes) > 0 {
+			s.updatedInvited = append(s.updatedInvited, rowUpdate{ID: current.ID, Version: input.Version, Changes: changes})
+		}
+	}
+
+	for i, input := range inputs {
+		path := fmt.Sprintf("invited[%d]", i)
+		switch {
+		case input.ID == 0:
+			s.newInvited = append(s.newInvited, input)
+		case len(input.Members) == 0:
+			diff(path, input, nil)
+		default:
+			// An existing household: its ID is the group's.
+			group, ok := groups[input.ID]
+			if !ok || groupListed(inputs[:i], group.ID) {
+				fields[path+".id"] = "Grupo não encontrado neste evento"
+				continue
+			}
+			keptGroups[group.ID] = true
+			if name := strings.TrimSpace(input.Name); name != group.Name {
+				group.Name = name
+				s.renamedGroups = append(s.renamedGroups, group)
+			}
+			for j, member := range input.Members {
+				memberPath := fmt.Sprintf("%s.members[%d]", path, j)
+				if member.ID == 0 {
+					s.newMembers = append(s.newMembers, groupMember{GroupID: group.ID, Input: member})
+					continue
+				}
+				diff(memberPath, member, &group.ID)
+			}
+		}
+	}
+
+	remaining := map[uint]int{}
+	for id, inv := range existing {
+		if !seen[id] {
+			s.deletedInvited = append(s.deletedInvited, inv)
+		} else if inv.GroupID != nil {
+			remaining[*inv.GroupID]++
+		}
+	}
+	for _, member := range s.newMembers {
+		remaining[member.GroupID]++
+	}
+	for id, group := range groups {
+		if !keptGroups[id] || remaining[id] == 0 {
+			s.deletedGroups = append(s.deletedGroups, group)
+		}
+	}
+}
+
+// groupListed reports whether a household entry for the group was already sent.
+func groupListed(inputs []CreateInvitedInput, groupID uint) bool {
+	for _, input := range inputs {
+		if input.ID == groupID && len(input.Members) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// planGiftSync diffs the gifts of the event against the payload. Gifts that
+// already have reservations or contributions are only deleted when forced;
+// the blocked ones are returned so the client can ask the organizer.
+func (s *nestedSync) planGiftSync(event models.Event, inputs []CreateGiftInput, force bool, fields map[string]string) []models.EventGift {
+	if inputs == nil {
+		return nil
+	}
+
+	s.force = force
+	existing := map[uint]models.EventGift{}
+	for _, gift := range event.Gifts {
+		existing[gift.ID] = gift
+	}
+
+	seen := map[uint]bool{}
+	for i, input := range inputs {
+		path := fmt.Sprintf("gifts[%d]", i)
+		desired, err := buildGift(event.ID, input)
+		if err != nil {
+			// Already reported by validateNestedInputs.
+			continue
+		}
+		if input.ID == 0 {
+			s.newGifts = append(s.newGifts, desired)
+			continue
+		}
+
+		current, ok := existing[input.ID]
+		if !ok || seen[input.ID] {
+			fields[path+".id"] = "Presente não encontrado neste evento"
+			continue
+		}
+		seen[input.ID] = true
+		if input.Version == 0 {
+			fields[path+".version"] = errVersionRequired
+			continue
+		}
+		if desired.Kind != current.Kind {
+			fields[path+".kind"] = "O tipo de um presente já cadastrado não pode ser alterado"
+			continue
+		}
+
+		changes := map[string]interface{}{}
+		if name := strings.TrimSpace(input.Name); name != current.Name {
+			changes["name"] = name
+		}
+		if desired.Link != current.Link {
+			changes["link"] = desired.Link
+		}
+		if current.Kind == models.GiftItem && input.MaxReservations != "" && desired.MaxReservations != current.MaxReservations {
+			if int(desired.MaxReservations) < len(current.Reservations) {
+				fields[path+".max_reservations"] = errBelowReserved.Error()
+				continue
+			}
+			changes["max_reservations"] = desired.MaxReservations
+		}
+		if current.Kind == models.GiftFund && desired.TargetCents != current.TargetCents {
+			changes["target_cents"] = desired.TargetCents
+		}
+		if len(changes) > 0 {
+			s.updatedGifts = append(s.updatedGifts, rowUpdate{ID: current.ID, Version: input.Version, Changes: changes})
+		}
+	}
+
+	var blocked []models.EventGift
+	for id, gift := range existing {
+		if seen[id] {
+			continue
+		}
+		if (len(gift.Reservations) > 0 || len(gift.Pledges) > 0) && !force {
+			blocked = append(blocked, gift)
+			continue
+		}
+		s.deletedGifts = append(s.deletedGifts, gift)
+	}
+	return blocked
+}
+
+// apply writes the planned changes inside the caller's transaction. Limits and
+// deletions are checked again with the rows locked, since reservations and RSVPs
+// may have arrived after the plan was made.
+func (s *nestedSync) apply(tx *gorm.DB, eventID uint) (EventChanges, error) {
+	var changes EventChanges
+
+	for _, input := range s.newInvited {
+		if len(input.Members) > 0 {
+			if _, err := createInviteGroup(tx, eventID, input); err != nil {
+				return changes, err
+			}
+		} else {
+			invited := newInvited(eventID, input)
+			if err := tx.Create(&invited).Error; err != nil {
+				return changes, err
+			}
+		}
+		changes.Invited.Created++
+	}
+	for _, member := range s.newMembers {
+		invited := newInvited(eventID, member.Input)
+		invited.GroupID = &member.GroupID
+		if err := tx.Create(&invited).Error; err != nil {
+			return changes, err
+		}
+		changes.Invited.Created++
+	}
+	for _, group := range s.renamedGroups {
+		if err := tx.Model(&models.InviteGroup{}).Where("id = ?", group.ID).Update("name", group.Name).Error; err != nil {
+			return changes, err
+		}
+	}
+	for _, update := range s.updatedInvited {
+		if limit, ok := update.Changes["max_companions"].(uint); ok {
+			var current models.EventInvited
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Companions").First(&current, update.ID).Error; err != nil {
+				return changes, err
+			}
+			if companionsNeeded(current) > int(limit) {
+				return changes, errBelowCompanions
+			}
+		}
+		if err := saveVersioned(tx, &models.EventInvited{}, update.ID, update.Version, update.Changes); err != nil {
+			return changes, err
+		}
+		changes.Invited.Updated++
+	}
+	for _, invite := range s.deletedInvited {
+		if err := deleteInvite(tx, invite); err != nil {
+			return changes, err
+		}
+		changes.Invited.Deleted++
+	}
+	for _, group := range s.deletedGroups {
+		if err := tx.Delete(&group).Error; err != nil {
+			return changes, err
+		}
+	}
+
+	for _, gift := range s.newGifts {
+		if err := tx.Create(&gift).Error; err != nil {
+			return changes, err
+		}
+		changes.Gifts.Created++
+	}
+	for _, update := range s.updatedGifts {
+		if limit, ok := update.Changes["max_reservations"].(uint); ok {
+			if err := lockGift(tx, update.ID); err != nil {
+				return changes, err
+			}
+			var reserved int64
+			if err := tx.Model(&models.GiftReservation{}).Where("event_gift_id = ?", update.ID).Count(&reserved).Error; err != nil {
+				return changes, err
+			}
+			if reserved > int64(limit) {
+				return changes, errBelowReserved
+			}
+		}
+		if err := saveVersioned(tx, &models.EventGift{}, update.ID, update.Version, update.Changes); err != nil {
+			return changes, err
+		}
+		changes.Gifts.Updated++
+	}
+	for i, gift := range s.deletedGifts {
+		if err := lockGift(tx, gift.ID); err != nil {
+			return changes, err
+		}
+		if err := tx.Preload("Reservations").Preload("Pledges").First(&gift, gift.ID).Error; err != nil {
+			return changes, err
+		}
+		if len(gift.Reservations)+len(gift.Pledges) > 0 && !s.force {
+			return changes, errGiftHasGivers
+		}
+		if err := deleteGift(tx, gift); err != nil {
+			return changes, err
+		}
+		// Kept with the fresh reservations, for notifyGiftRemoved.
+		s.deletedGifts[i] = gift
+		changes.Gifts.Deleted++
+	}
+
+	return changes, nil
+}
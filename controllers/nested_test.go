@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"gorm.io/gorm"
+)
+
+func updatePayload(invited []gin.H, gifts []gin.H) gin.H {
+	body := eventPayload(gifts...)
+	body["invited"] = invited
+	if gifts == nil {
+		body["gifts"] = []gin.H{}
+	}
+	return body
+}
+
+func TestUpdateEventMatchesHouseholdsByID(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	group, err := createInviteGroup(ctrl.DB, event.ID, CreateInvitedInput{
+		Name:    "Família Souza",
+		Members: []CreateInvitedInput{{Name: "Ana"}, {Name: "Léo"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ana, leo := group.Members[0], group.Members[1]
+
+	r := gin.New()
+	r.PUT("/events/:id", withUser(owner.ID), ctrl.UpdateEvent)
+	path := fmt.Sprintf("/events/%d", event.ID)
+
+	household := gin.H{"id": group.ID, "name": "Família Souza Lima", "members": []gin.H{
+		{"id": ana.ID, "version": ana.Version, "name": "Ana"},
+		{"id": leo.ID, "version": leo.Version, "name": "Léo"},
+		{"name": "Bebê"},
+	}}
+	for i := 0; i < 2; i++ {
+		if i == 1 {
+			// The new member now exists and must be sent with its ID.
+			var baby models.EventInvited
+			ctrl.DB.Where("name = ?", "Bebê").First(&baby)
+			household["members"] = []gin.H{
+				{"id": ana.ID, "version": ana.Version, "name": "Ana"},
+				{"id": leo.ID, "version": leo.Version, "name": "Léo"},
+				{"id": baby.ID, "version": baby.Version, "name": "Bebê"},
+			}
+		}
+		w, _ := doJSON(r, http.MethodPut, path, updatePayload([]gin.H{household}, nil), nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("PUT %d: status %d: %s", i+1, w.Code, w.Body)
+		}
+	}
+
+	var groups, members int64
+	ctrl.DB.Model(&models.InviteGroup{}).Where("event_id = ?", event.ID).Count(&groups)
+	ctrl.DB.Model(&models.EventInvited{}).Where("event_id = ? AND group_id = ?", event.ID, group.ID).Count(&members)
+	if groups != 1 || members != 3 {
+		t.Fatalf("%d grupo(s) e %d membro(s) após dois PUTs", groups, members)
+	}
+	var saved models.InviteGroup
+	ctrl.DB.First(&saved, group.ID)
+	if saved.Name != "Família Souza Lima" || saved.UUID != group.UUID {
+		t.Fatalf("grupo não atualizado no lugar: %+v", saved)
+	}
+
+	// Sending the members flat keeps them in their household.
+	flat := []gin.H{{"id": ana.ID, "version": ana.Version, "name": "Ana"}}
+	if w, _ := doJSON(r, http.MethodPut, path, updatePayload(flat, nil), nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var kept models.EventInvited
+	ctrl.DB.First(&kept, ana.ID)
+	if kept.GroupID == nil || *kept.GroupID != group.ID {
+		t.Fatalf("membro saiu do grupo: %+v", kept)
+	}
+	ctrl.DB.Model(&models.EventInvited{}).Where("event_id = ?", event.ID).Count(&members)
+	if members != 1 {
+		t.Fatalf("%d convidados, esperado só a Ana", members)
+	}
+
+	// A PUT without the household removes it.
+	if w, _ := doJSON(r, http.MethodPut, path, updatePayload([]gin.H{}, nil), nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	ctrl.DB.Model(&models.InviteGroup{}).Where("event_id = ?", event.ID).Count(&groups)
+	if groups != 0 {
+		t.Fatalf("grupo sem membros não foi removido")
+	}
+}
+
+func TestUpdateEventRequiresVersion(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	invite := createInvite(t, ctrl.DB, event.ID, "Ana")
+	gift := models.EventGift{EventID: event.ID, Name: "Carrinho", Kind: models.GiftItem, MaxReservations: 1}
+	ctrl.DB.Create(&gift)
+
+	r := gin.New()
+	r.PUT("/events/:id", withUser(owner.ID), ctrl.UpdateEvent)
+	w, body := doJSON(r, http.MethodPut, fmt.Sprintf("/events/%d", event.ID), updatePayload(
+		[]gin.H{{"id": invite.ID, "name": "Ana Souza"}},
+		[]gin.H{{"id": gift.ID, "name": "Berço"}},
+	), nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	fields := body["fields"].(map[string]interface{})
+	if fields["invited[0].version"] == nil || fields["gifts[0].version"] == nil {
+		t.Fatalf("versão ausente não apontada: %v", fields)
+	}
+}
+
+func TestUpdateEventRechecksReservationsUnderLock(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	invite := createInvite(t, ctrl.DB, event.ID, "Ana")
+	gift := models.EventGift{EventID: event.ID, Name: "Carrinho", Kind: models.GiftItem, MaxReservations: 1}
+	ctrl.DB.Create(&gift)
+
+	// A guest reserves the gift right after the update loaded the event.
+	var once sync.Once
+	ctrl.DB.Callback().Query().After("gorm:query").Register("test:concurrent_reservation", func(tx *gorm.DB) {
+		if tx.Statement.Table != "gift_reservations" {
+			return
+		}
+		once.Do(func() {
+			ctrl.DB.Create(&models.GiftReservation{EventGiftID: gift.ID, InviteUUID: invite.UUID})
+		})
+	})
+
+	r := gin.New()
+	r.PUT("/events/:id", withUser(owner.ID), ctrl.UpdateEvent)
+	invited := []gin.H{{"id": invite.ID, "version": invite.Version, "name": "Ana"}}
+	w, body := doJSON(r, http.MethodPut, fmt.Sprintf("/events/%d", event.ID), updatePayload(invited, nil), nil)
+	if w.Code != http.StatusConflict || body["code"] != "gift_has_givers" {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	var reservations int64
+	ctrl.DB.Model(&models.GiftReservation{}).Where("event_gift_id = ?", gift.ID).Count(&reservations)
+	if err := ctrl.DB.First(&models.EventGift{}, gift.ID).Error; err != nil || reservations != 1 {
+		t.Fatalf("presente reservado removido sem force: %v, %d reserva(s)", err, reservations)
+	}
+}
+
+func TestCreateEventWithNestedGuestsAndGifts(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	r := gin.New()
+	r.POST("/events", withUser(owner.ID), ctrl.CreateEvent)
+
+	invited := []gin.H{
+		{"name": "Bia", "whatsapp": "(11) 98765-4321"},
+		{"name": "Família Souza", "members": []gin.H{{"name": "Ana"}, {"name": "Pedro"}}},
+	}
+	gifts := []gin.H{
+		{"name": "Carrinho", "max_reservations": "2"},
+		{"name": "Fundo do berço", "kind": "fundo", "target_cents": 50000},
+	}
+
+	counts := func() (events, guests, groups, eventGifts int64) {
+		ctrl.DB.Model(&models.Event{}).Count(&events)
+		ctrl.DB.Model(&models.EventInvited{}).Count(&guests)
+		ctrl.DB.Model(&models.InviteGroup{}).Count(&groups)
+		ctrl.DB.Model(&models.EventGift{}).Count(&eventGifts)
+		return
+	}
+
+	// A failure on the last gift rolls back the event and everything before it.
+	fail := true
+	ctrl.DB.Callback().Create().Before("gorm:create").Register("test:fail_fund", func(tx *gorm.DB) {
+		if gift, ok := tx.Statement.Dest.(*models.EventGift); fail && ok && gift.Kind == models.GiftFund {
+			tx.AddError(fmt.Errorf("disco cheio"))
+		}
+	})
+	if w, _ := doJSON(r, http.MethodPost, "/events", updatePayload(invited, gifts), nil); w.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if events, guests, groups, eventGifts := counts(); events+guests+groups+eventGifts != 0 {
+		t.Fatalf("sobrou %d evento(s), %d convidado(s), %d grupo(s) e %d presente(s)", events, guests, groups, eventGifts)
+	}
+
+	fail = false
+	w, _ := doJSON(r, http.MethodPost, "/events", updatePayload(invited, gifts), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if events, guests, groups, eventGifts := counts(); events != 1 || guests != 3 || groups != 1 || eventGifts != 2 {
+		t.Fatalf("criou %d evento(s), %d convidado(s), %d grupo(s) e %d presente(s)", events, guests, groups, eventGifts)
+	}
+
+	var bia models.EventInvited
+	ctrl.DB.Where("name = ?", "Bia").First(&bia)
+	var stroller models.EventGift
+	ctrl.DB.Where("name = ?", "Carrinho").First(&stroller)
+	if bia.Whatsapp != "5511987654321" || bia.GroupID != nil || stroller.MaxReservations != 2 {
+		t.Fatalf("convidada %+v, presente %+v", bia, stroller)
+	}
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/notifications"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const passwordResetTTL = time.Hour
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordInput struct {
+	Token string `json:"token" binding:"required"`
+	Senha string `json:"senha" binding:"required,min=6"`
+}
+
+var errResetTokenInvalid = errors.New("Link de redefinição inválido ou expirado")
+
+// ForgotPassword always answers the same way, so it can't be used to find out
+// which e-mails have an account.
+func ForgotPassword(c *gin.Context, db *gorm.DB, mailer notifications.Mailer) {
+	var input ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	baseURL, err := emailBaseURL()
+	if err != nil {
+		log.Printf("redefinição de senha: %v, link não enviado", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível enviar o link de redefinição"})
+		return
+	}
+
+	response := gin.H{"message": "Se o e-mail estiver cadastrado, você receberá um link para redefinir a senha"}
+
+	var user models.User
+	if err := db.Where("email = ?", strings.ToLower(strings.TrimSpace(input.Email))).First(&user).Error; err != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	// Same answer during the cooldown, so it can't be used to probe accounts either.
+	if mailCooldownLeft(db, &models.PasswordReset{}, user.ID) > 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar o link de redefinição"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Only the latest link works.
+		if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.PasswordReset{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordReset{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(passwordResetTTL),
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar o link de redefinição"})
+		return
+	}
+
+	msg := notifications.Message{
+		To:      user.Email,
+		Subject: "Redefinição de senha",
+		Body: "Olá, " + user.NomeCompleto + "!\n\n" +
+			"Para criar uma nova senha, acesse o link abaixo em até 1 hora:\n" +
+			baseURL + "/password/reset?token=" + token + "\n\n" +
+			"Se você não pediu a redefinição, ignore este e-mail.",
+	}
+	go func() {
+		if err := mailer.Send(msg); err != nil {
+			log.Printf("redefinição de senha do usuário %d: %v", user.ID, err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword consumes the token, sets the new password and logs out every
+// existing session of the user.
+func ResetPassword(c *gin.Context, db *gorm.DB) {
+	var input ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Senha), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível redefinir a senha"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var reset models.PasswordReset
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", utils.HashToken(input.Token), time.Now()).
+			First(&reset).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errResetTokenInvalid
+			}
+			return err
+		}
+
+		if err := tx.Model(&reset).Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", reset.UserID).Update("senha", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return revokeUserSessions(tx, reset.UserID)
+	})
+	if errors.Is(err, errResetTokenInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível redefinir a senha"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Senha redefinida com sucesso, faça login novamente"})
+}
+
+// ServeResetPage shows the form opened from the e-mail link.
+func ServeResetPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "reset_password.html", gin.H{})
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	middleware "github.com/pedroShimpa/cha-de-bebe-api/middlewares"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/notifications"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordResetFlow(t *testing.T) {
+	ctrl := newTestController(t)
+	db := ctrl.DB
+	mailer := &notifications.MemoryMailer{}
+
+	user := createUser(t, db, "ana@example.com")
+	hash, _ := bcrypt.GenerateFromPassword([]byte("antiga123"), bcrypt.MinCost)
+	db.Model(&user).Update("senha", string(hash))
+	db.First(&user, user.ID)
+	session, err := issueSession(db, user, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.POST("/login", func(c *gin.Context) { Login(c, db) })
+	r.POST("/token/refresh", func(c *gin.Context) { RefreshSession(c, db) })
+	r.POST("/password/forgot", func(c *gin.Context) { ForgotPassword(c, db, mailer) })
+	r.POST("/password/reset", func(c *gin.Context) { ResetPassword(c, db) })
+	r.GET("/me", middleware.AuthMiddleware(db), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// Unknown e-mails get the same answer and no mail.
+	w, unknown := doJSON(r, "POST", "/password/forgot", gin.H{"email": "ninguem@example.com"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("e-mail desconhecido: %d", w.Code)
+	}
+	w, known := doJSON(r, "POST", "/password/forgot", gin.H{"email": "ANA@example.com"}, nil)
+	if w.Code != http.StatusOK || known["message"] != unknown["message"] {
+		t.Fatalf("respostas diferentes: %v / %v", known, unknown)
+	}
+	msg, token := waitForMail(t, mailer, 1)
+	if msg.To != user.Email || token == "" || len(mailer.Sent()) != 1 {
+		t.Fatalf("e-mail inesperado: %+v (%d enviados)", msg, len(mailer.Sent()))
+	}
+	if !strings.Contains(msg.Body, "https://cha.example.com/password/reset?token=") {
+		t.Fatalf("link fora de APP_URL:\n%s", msg.Body)
+	}
+
+	// Within the cooldown the answer is the same, but nothing is sent.
+	if w, again := doJSON(r, "POST", "/password/forgot", gin.H{"email": user.Email}, nil); w.Code != http.StatusOK || again["message"] != known["message"] {
+		t.Fatalf("pedido repetido: %d %v", w.Code, again)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if sent := len(mailer.Sent()); sent != 1 {
+		t.Fatalf("%d e-mails enviados durante o intervalo", sent)
+	}
+
+	w, body := doJSON(r, "POST", "/password/reset", gin.H{"token": token, "senha": "nova1234"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("redefinição: %d %v", w.Code, body)
+	}
+	if w, _ := doJSON(r, "POST", "/password/reset", gin.H{"token": token, "senha": "outra1234"}, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("token reutilizado: %d", w.Code)
+	}
+
+	// The sessions started before the reset no longer work.
+	if w, _ := doJSON(r, "POST", "/token/refresh", gin.H{"refresh_token": session["refresh_token"]}, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh antigo: %d", w.Code)
+	}
+	header := http.Header{"Authorization": {"Bearer " + session["token"].(string)}}
+	if w, _ := doJSON(r, "GET", "/me", nil, header); w.Code != http.StatusUnauthorized {
+		t.Fatalf("access token antigo: %d", w.Code)
+	}
+
+	if w, _ := doJSON(r, "POST", "/login", gin.H{"email": user.Email, "senha": "antiga123"}, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("senha antiga aceita: %d", w.Code)
+	}
+	w, login := doJSON(r, "POST", "/login", gin.H{"email": user.Email, "senha": "nova1234"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login com a nova senha: %d %v", w.Code, login)
+	}
+	header = http.Header{"Authorization": {"Bearer " + login["token"].(string)}}
+	if w, _ := doJSON(r, "GET", "/me", nil, header); w.Code != http.StatusOK {
+		t.Fatalf("novo access token: %d", w.Code)
+	}
+
+	var reset models.PasswordReset
+	if err := db.Where("user_id = ?", user.ID).First(&reset).Error; err != nil || reset.UsedAt == nil {
+		t.Fatalf("link não marcado como usado: %+v %v", reset, err)
+	}
+}
+
+func TestForgotPasswordIgnoresRequestHost(t *testing.T) {
+	ctrl := newTestController(t)
+	mailer := &notifications.MemoryMailer{}
+	createUser(t, ctrl.DB, "ana@example.com")
+
+	r := gin.New()
+	r.POST("/password/forgot", func(c *gin.Context) { ForgotPassword(c, ctrl.DB, mailer) })
+	forged := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/password/forgot", strings.NewReader(`{"email":"ana@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Host = "atacante.example"
+		req.Header.Set("X-Forwarded-Proto", "https")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Without APP_URL there is no trustworthy address for the link.
+	t.Setenv("APP_URL", "")
+	if w := forged(); w.Code != http.StatusInternalServerError {
+		t.Fatalf("sem APP_URL: status %d", w.Code)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if sent := len(mailer.Sent()); sent != 0 {
+		t.Fatalf("%d e-mails enviados sem APP_URL", sent)
+	}
+
+	t.Setenv("APP_URL", "https://cha.example.com/")
+	if w := forged(); w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	msg, _ := waitForMail(t, mailer, 1)
+	if strings.Contains(msg.Body, "atacante") || !strings.Contains(msg.Body, "https://cha.example.com/password/reset?token=") {
+		t.Fatalf("link montado a partir do Host:\n%s", msg.Body)
+	}
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"github.com/skip2/go-qrcode"
+)
+
+// pixPayloadFor builds the BR Code for the event, falling back to the organizer's
+// name when the event has no explicit receiver name.
+func (ctrl *Controller) pixPayloadFor(event models.Event, amountCents int64, txID string) (string, utils.PixKeyType, error) {
+	key, keyType, err := utils.NormalizePixKey(event.PixKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	name := event.PixName
+	if name == "" {
+		var owner models.User
+		if err := ctrl.DB.First(&owner, event.UserID).Error; err == nil {
+			name = owner.NomeCompleto
+		}
+	}
+	payload := utils.BuildPixPayload(utils.PixPayload{
+		Key:          key,
+		MerchantName: name,
+		MerchantCity: event.PixCity,
+		AmountCents:  amountCents,
+		TxID:         txID,
+	})
+	return payload, keyType, nil
+}
+
+func (ctrl *Controller) GetInvitePix(c *gin.Context) {
+	inviteUUID := c.Param("uuid")
+
+	var invite models.EventInvited
+	if err := ctrl.DB.Where("uuid = ?", inviteUUID).First(&invite).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Convite não encontrado"})
+		return
+	}
+
+	var event models.Event
+	if err := ctrl.DB.First(&event, invite.EventID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento não encontrado"})
+		return
+	}
+	if hideDraft(c, event) {
+		return
+	}
+	if event.PixKey == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Este evento não possui chave Pix"})
+		return
+	}
+
+	var amountCents int64
+	if raw := c.Query("amount_cents"); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || v <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Valor inválido"})
+			return
+		}
+		amountCents = v
+	}
+
+	payload, keyType, err := ctrl.pixPayloadFor(event, amountCents, invite.UUID)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch c.Query("format") {
+	case "png":
+		png, err := qrcode.Encode(payload, qrcode.Medium, 320)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar o QR Code"})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", png)
+		return
+	case "svg":
+		svg, err := utils.QRCodeSVG(payload, 320)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar o QR Code"})
+			return
+		}
+		c.Data(http.StatusOK, "image/svg+xml", []byte(svg))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"payload": payload, "key_type": keyType})
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"gorm.io/gorm"
+)
+
+type PledgeGiftInput struct {
+	InviteUUID  string `json:"invite_uuid" binding:"required"`
+	EventGiftID uint   `json:"event_gift_id" binding:"required"`
+	AmountCents int64  `json:"amount_cents" binding:"required,gt=0"`
+}
+
+func (ctrl *Controller) PledgeGift(c *gin.Context) {
+	var input PledgeGiftInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var pledge models.GiftPledge
+	var gift models.EventGift
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		invite, err := findReservingInvite(tx, input.InviteUUID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&gift, input.EventGiftID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errGiftNotFound
+			}
+			return err
+		}
+		if gift.EventID != invite.EventID {
+			return errInviteWrongEvent
+		}
+		if gift.Kind != models.GiftFund {
+			return errGiftIsNotFund
+		}
+
+		pledge = models.GiftPledge{
+			EventGiftID: gift.ID,
+			InviteUUID:  invite.UUID,
+			AmountCents: input.AmountCents,
+		}
+		if err := tx.Create(&pledge).Error; err != nil {
+			return err
+		}
+
+		// Bump the total in SQL so concurrent pledges never overwrite each other.
+		if err := tx.Model(&gift).
+			UpdateColumn("pledged_cents", gorm.Expr("pledged_cents + ?", input.AmountCents)).Error; err != nil {
+			return err
+		}
+		return tx.First(&gift, gift.ID).Error
+	})
+	if err != nil {
+		respondReservationError(c, err, "Não foi possível registrar a contribuição")
+		return
+	}
+
+	response := gin.H{"message": "Contribuição registrada com sucesso", "pledge": pledge, "gift": gift}
+
+	var event models.Event
+	if err := ctrl.DB.First(&event, gift.EventID).Error; err == nil && event.PixKey != "" {
+		if payload, _, err := ctrl.pixPayloadFor(event, pledge.AmountCents, input.InviteUUID); err == nil {
+			response["pix_payload"] = payload
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReserveGiftInput struct {
+	InviteUUID  string `json:"invite_uuid" binding:"required"`
+	EventGiftID uint   `json:"event_gift_id" binding:"required"`
+}
+
+type SwapReservationInput struct {
+	EventGiftID uint `json:"event_gift_id" binding:"required"`
+}
+
+// findOpenInvite loads the invite by UUID and checks that its event still accepts gift changes.
+func findOpenInvite(tx *gorm.DB, inviteUUID string) (models.EventInvited, error) {
+	var invite models.EventInvited
+	if err := tx.Where("uuid = ?", inviteUUID).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return invite, errInviteNotFound
+		}
+		return invite, err
+	}
+
+	var event models.Event
+	if err := tx.Select("id", "status", "reservation_deadline").First(&event, invite.EventID).Error; err != nil {
+		return invite, err
+	}
+	if !event.Status.AcceptsReservations() {
+		return invite, errEventClosed
+	}
+	if !event.ReservationsOpen(time.Now()) {
+		return invite, errReservationDeadline
+	}
+	return invite, nil
+}
+
+// findReservingInvite loads the invite by UUID and makes sure it is still allowed to hold reservations.
+func findReservingInvite(tx *gorm.DB, inviteUUID string) (models.EventInvited, error) {
+	invite, err := findOpenInvite(tx, inviteUUID)
+	if err != nil {
+		return invite, err
+	}
+	if invite.Accepted != nil && !*invite.Accepted {
+		return invite, errInviteDeclined
+	}
+	return invite, nil
+}
+
+// lockGift takes the same row lock as lockGiftSlot without checking for a free slot.
+func lockGift(tx *gorm.DB, giftID uint) error {
+	var gift models.EventGift
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&gift, giftID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errGiftNotFound
+	}
+	return err
+}
+
+// lockGiftSlot locks the gift row until the end of the transaction, so concurrent
+// reservations can't both pass the limit check, and verifies a slot is still free.
+func lockGiftSlot(tx *gorm.DB, giftID uint, invite models.EventInvited) (models.EventGift, error) {
+	var gift models.EventGift
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&gift, giftID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return gift, errGiftNotFound
+		}
+		return gift, err
+	}
+	if invite.EventID != gift.EventID {
+		return gift, errInviteWrongEvent
+	}
+	if gift.Kind == models.GiftFund {
+		return gift, errGiftIsFund
+	}
+
+	var reserved int64
+	if err := tx.Model(&models.GiftReservation{}).Where("event_gift_id = ?", gift.ID).Count(&reserved).Error; err != nil {
+		return gift, err
+	}
+	if uint(reserved) >= gift.MaxReservations {
+		return gift, errReservationLimit
+	}
+	return gift, nil
+}
+
+func (ctrl *Controller) ReserveGift(c *gin.Context) {
+	var input ReserveGiftInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var reservation models.GiftReservation
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		invite, err := findReservingInvite(tx, input.InviteUUID)
+		if err != nil {
+			return err
+		}
+
+		gift, err := lockGiftSlot(tx, input.EventGiftID, invite)
+		if err != nil {
+			return err
+		}
+
+		reservation = models.GiftReservation{
+			EventGiftID: gift.ID,
+			InviteUUID:  invite.UUID,
+		}
+		if err := tx.Create(&reservation).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errAlreadyReserved
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		respondReservationError(c, err, "Não foi possível reservar o presente")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Presente reservado com sucesso", "reservation": reservation})
+}
+
+const cancelFailure = "Não foi possível cancelar a reserva"
+
+func (ctrl *Controller) CancelReservation(c *gin.Context) {
+	inviteUUID := c.Param("uuid")
+	reservationID := c.Param("id")
+
+	invite, err := findOpenInvite(ctrl.DB, inviteUUID)
+	if err != nil {
+		respondReservationError(c, err, cancelFailure)
+		return
+	}
+
+	// Reservations are removed for good so the unique (gift, invite) index
+	// lets the guest reserve the same gift again later.
+	result := ctrl.DB.Unscoped().
+		Where("id = ? AND invite_uuid = ?", reservationID, invite.UUID).
+		Delete(&models.GiftReservation{})
+	if result.Error != nil {
+		respondReservationError(c, result.Error, cancelFailure)
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondReservationError(c, errReservationNotFound, cancelFailure)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reserva cancelada com sucesso"})
+}
+
+func (ctrl *Controller) SwapReservation(c *gin.Context) {
+	inviteUUID := c.Param("uuid")
+	reservationID := c.Param("id")
+
+	var input SwapReservationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var reservation models.GiftReservation
+	err := ctrl.DB.Transaction(func(tx *gorm.DB) error {
+		invite, err := findReservingInvite(tx, inviteUUID)
+		if err != nil {
+			return err
+		}
+
+		var current models.GiftReservation
+		if err := tx.Where("id = ? AND invite_uuid = ?", reservationID, invite.UUID).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errReservationNotFound
+			}
+			return err
+		}
+		if current.EventGiftID == input.EventGiftID {
+			return errAlreadyReserved
+		}
+
+		// Both gifts are locked in ascending ID order, so swaps going in opposite
+		// directions wait for each other instead of deadlocking.
+		var gift models.EventGift
+		if current.EventGiftID < input.EventGiftID {
+			if err := lockGift(tx, current.EventGiftID); err != nil {
+				return err
+			}
+			if gift, err = lockGiftSlot(tx, input.EventGiftID, invite); err != nil {
+				return err
+			}
+		} else {
+			if gift, err = lockGiftSlot(tx, input.EventGiftID, invite); err != nil {
+				return err
+			}
+			if err := lockGift(tx, current.EventGiftID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND invite_uuid = ? AND event_gift_id = ?", current.ID, invite.UUID, current.EventGiftID).
+			First(&reservation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errReservationNotFound
+			}
+			return err
+		}
+
+		reservation.EventGiftID = gift.ID
+		if err := tx.Save(&reservation).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errAlreadyReserved
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		respondReservationError(c, err, "Não foi possível trocar a reserva")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reserva trocada com sucesso", "reservation": reservation})
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errGiftNotFound        = errors.New("Presente não encontrado")
+	errInviteNotFound      = errors.New("Convite não encontrado")
+	errInviteWrongEvent    = errors.New("Este convite não pertence ao evento do presente")
+	errInviteDeclined      = errors.New("Convites recusados não podem reservar presentes")
+	errReservationLimit    = errors.New("Limite de reservas atingido")
+	errAlreadyReserved     = errors.New("Você já reservou este presente")
+	errReservationNotFound = errors.New("Reserva não encontrada")
+	errGiftIsFund          = errors.New("Este presente é um fundo, contribua com um valor")
+	errGiftIsNotFund       = errors.New("Este presente não aceita contribuições em dinheiro")
+	errEventClosed         = errors.New("Este evento não está aceitando reservas de presentes")
+	errReservationDeadline = errors.New("O prazo para reservar presentes terminou")
+)
+
+// respondReservationError maps the gift errors to their status and code; anything
+// else is a server error answered with the action's own message.
+func respondReservationError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, errGiftNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "gift_not_found"})
+	case errors.Is(err, errReservationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "reservation_not_found"})
+	case errors.Is(err, errInviteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "invite_not_found"})
+	case errors.Is(err, errInviteWrongEvent):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "invite_wrong_event"})
+	case errors.Is(err, errInviteDeclined):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "invite_declined"})
+	case errors.Is(err, errGiftIsFund):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "gift_is_fund"})
+	case errors.Is(err, errGiftIsNotFund):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "gift_is_not_fund"})
+	case errors.Is(err, errEventClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "event_closed"})
+	case errors.Is(err, errReservationDeadline):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "reservation_deadline"})
+	case errors.Is(err, errReservationLimit):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "reservation_limit"})
+	case errors.Is(err, errAlreadyReserved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "already_reserved"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+	}
+}
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"gorm.io/gorm"
+)
+
+func TestReserveGiftNeverExceedsLimit(t *testing.T) {
+	ctrl := newTestController(t)
+	r := gin.New()
+	r.POST("/gifts/reserve", ctrl.ReserveGift)
+
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	gift := models.EventGift{EventID: event.ID, Name: "Carrinho", Kind: models.GiftItem, MaxReservations: 7}
+	if err := ctrl.DB.Create(&gift).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	const guests = 300
+	invites := make([]models.EventInvited, guests)
+	for i := range invites {
+		invites[i] = createInvite(t, ctrl.DB, event.ID, fmt.Sprintf("Convidado %d", i))
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	statuses := make([]int, guests)
+	codes := make([]interface{}, guests)
+	for i, invite := range invites {
+		wg.Add(1)
+		go func(i int, uuid string) {
+			defer wg.Done()
+			<-start
+			w, body := doJSON(r, http.MethodPost, "/gifts/reserve", gin.H{"invite_uuid": uuid, "event_gift_id": gift.ID}, nil)
+			statuses[i] = w.Code
+			codes[i] = body["code"]
+		}(i, invite.UUID)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for i, status := range statuses {
+		switch {
+		case status == http.StatusOK:
+			succeeded++
+		case status == http.StatusConflict && codes[i] == "reservation_limit":
+		default:
+			t.Errorf("reserva %d: status %d, code %v", i, status, codes[i])
+		}
+	}
+
+	var reserved int64
+	if err := ctrl.DB.Model(&models.GiftReservation{}).Where("event_gift_id = ?", gift.ID).Count(&reserved).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reserved > int64(gift.MaxReservations) {
+		t.Fatalf("%d reservas para um limite de %d", reserved, gift.MaxReservations)
+	}
+	if reserved != int64(succeeded) {
+		t.Fatalf("%d reservas gravadas, mas %d respostas de sucesso", reserved, succeeded)
+	}
+	if reserved != int64(gift.MaxReservations) {
+		t.Fatalf("esperava preencher as %d vagas, ficaram %d", gift.MaxReservations, reserved)
+	}
+}
+
+func TestOppositeSwapsDoNotFail(t *testing.T) {
+	ctrl := newTestController(t)
+	r := gin.New()
+	r.POST("/invites/:uuid/reservations/:id/swap", ctrl.SwapReservation)
+
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	giftA := models.EventGift{EventID: event.ID, Name: "Berço", Kind: models.GiftItem, MaxReservations: 50}
+	giftB := models.EventGift{EventID: event.ID, Name: "Banheira", Kind: models.GiftItem, MaxReservations: 50}
+	ctrl.DB.Create(&giftA)
+	ctrl.DB.Create(&giftB)
+
+	const pairs = 20
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	statuses := make(chan int, 2*pairs)
+	for i := 0; i < pairs; i++ {
+		for _, from := range []models.EventGift{giftA, giftB} {
+			to := giftB
+			if from.ID == giftB.ID {
+				to = giftA
+			}
+			invite := createInvite(t, ctrl.DB, event.ID, fmt.Sprintf("Convidado %d-%d", i, from.ID))
+			reservation := models.GiftReservation{EventGiftID: from.ID, InviteUUID: invite.UUID}
+			ctrl.DB.Create(&reservation)
+
+			wg.Add(1)
+			go func(path string, giftID uint) {
+				defer wg.Done()
+				<-start
+				w, _ := doJSON(r, http.MethodPost, path, gin.H{"event_gift_id": giftID}, nil)
+				statuses <- w.Code
+			}(fmt.Sprintf("/invites/%s/reservations/%d/swap", invite.UUID, reservation.ID), to.ID)
+		}
+	}
+	close(start)
+	wg.Wait()
+	close(statuses)
+
+	for status := range statuses {
+		if status != http.StatusOK {
+			t.Fatalf("troca respondeu %d", status)
+		}
+	}
+}
+
+func TestReserveGiftInviteErrors(t *testing.T) {
+	ctrl := newTestController(t)
+	r := gin.New()
+	r.POST("/gifts/reserve", ctrl.ReserveGift)
+
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	other := createEvent(t, ctrl.DB, owner.ID)
+	gift := models.EventGift{EventID: event.ID, Name: "Carrinho", Kind: models.GiftItem, MaxReservations: 5}
+	ctrl.DB.Create(&gift)
+
+	outsider := createInvite(t, ctrl.DB, other.ID, "Convidada de outro chá")
+	declined := createInvite(t, ctrl.DB, event.ID, "Recusou")
+	ctrl.DB.Model(&declined).Update("accepted", false)
+
+	cases := []struct {
+		name   string
+		uuid   string
+		status int
+		code   string
+	}{
+		{"convite inexistente", "nao-existe", http.StatusNotFound, "invite_not_found"},
+		{"convite de outro evento", outsider.UUID, http.StatusForbidden, "invite_wrong_event"},
+		{"convite recusado", declined.UUID, http.StatusForbidden, "invite_declined"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, body := doJSON(r, http.MethodPost, "/gifts/reserve", gin.H{"invite_uuid": tc.uuid, "event_gift_id": gift.ID}, nil)
+			if w.Code != tc.status || body["code"] != tc.code {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+		})
+	}
+
+	var reservations int64
+	ctrl.DB.Model(&models.GiftReservation{}).Where("event_gift_id = ?", gift.ID).Count(&reservations)
+	if reservations != 0 {
+		t.Fatalf("%d reserva(s) criada(s) por convites inválidos", reservations)
+	}
+}
+
+func TestInviteEventShowsOwnReservations(t *testing.T) {
+	ctrl := newTestController(t)
+	r := gin.New()
+	r.POST("/gifts/reserve", ctrl.ReserveGift)
+	r.GET("/invites/:uuid/event", ctrl.GetEventByInvite)
+
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	stroller := models.EventGift{EventID: event.ID, Name: "Carrinho", Kind: models.GiftItem, MaxReservations: 2}
+	bath := models.EventGift{EventID: event.ID, Name: "Banheira", Kind: models.GiftItem, MaxReservations: 1}
+	ctrl.DB.Create(&stroller)
+	ctrl.DB.Create(&bath)
+	ana := createInvite(t, ctrl.DB, event.ID, "Ana")
+	bia := createInvite(t, ctrl.DB, event.ID, "Bia")
+
+	for _, giftID := range []uint{stroller.ID, bath.ID} {
+		if w, _ := doJSON(r, http.MethodPost, "/gifts/reserve", gin.H{"invite_uuid": ana.UUID, "event_gift_id": giftID}, nil); w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+	}
+
+	view := func(uuid string) (gifts []string, mine []string) {
+		t.Helper()
+		w, _ := doJSON(r, http.MethodGet, "/invites/"+uuid+"/event", nil, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+		var body struct {
+			Event struct {
+				Gifts []models.EventGift `json:"gifts"`
+			} `json:"event"`
+			MyReservations []models.GiftReservation `json:"my_reservations"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		for _, g := range body.Event.Gifts {
+			gifts = append(gifts, g.Name)
+		}
+		for _, res := range body.MyReservations {
+			if res.Gift == nil {
+				t.Fatalf("reserva sem o presente: %s", w.Body)
+			}
+			mine = append(mine, res.Gift.Name)
+		}
+		return gifts, mine
+	}
+
+	// Ana sees her reservations apart from the gift list; Bia can still take the
+	// stroller's second slot but not the bath.
+	if gifts, mine := view(ana.UUID); len(gifts) != 0 || strings.Join(mine, ",") != "Carrinho,Banheira" {
+		t.Fatalf("Ana vê presentes %v e reservas %v", gifts, mine)
+	}
+	if gifts, mine := view(bia.UUID); strings.Join(gifts, ",") != "Carrinho" || len(mine) != 0 {
+		t.Fatalf("Bia vê presentes %v e reservas %v", gifts, mine)
+	}
+}
+
+func TestReservationFailuresNameTheAction(t *testing.T) {
+	ctrl := newTestController(t)
+	r := gin.New()
+	r.POST("/gifts/reserve", ctrl.ReserveGift)
+	r.POST("/invites/:uuid/reservations/:id/swap", ctrl.SwapReservation)
+
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	giftA := models.EventGift{EventID: event.ID, Name: "Berço", Kind: models.GiftItem, MaxReservations: 1}
+	giftB := models.EventGift{EventID: event.ID, Name: "Banheira", Kind: models.GiftItem, MaxReservations: 1}
+	ctrl.DB.Create(&giftA)
+	ctrl.DB.Create(&giftB)
+	invite := createInvite(t, ctrl.DB, event.ID, "Bia")
+	reservation := models.GiftReservation{EventGiftID: giftA.ID, InviteUUID: invite.UUID}
+	ctrl.DB.Create(&reservation)
+
+	fail := func(tx *gorm.DB) {
+		if tx.Statement.Table == "gift_reservations" {
+			tx.AddError(errors.New("disco cheio"))
+		}
+	}
+	ctrl.DB.Callback().Create().Before("gorm:create").Register("test:fail_reservations", fail)
+	ctrl.DB.Callback().Update().Before("gorm:update").Register("test:fail_reservations", fail)
+
+	cases := []struct {
+		name string
+		path string
+		body gin.H
+		want string
+	}{
+		{"reserva", "/gifts/reserve", gin.H{"invite_uuid": invite.UUID, "event_gift_id": giftB.ID}, "Não foi possível reservar o presente"},
+		{"troca", fmt.Sprintf("/invites/%s/reservations/%d/swap", invite.UUID, reservation.ID), gin.H{"event_gift_id": giftB.ID}, "Não foi possível trocar a reserva"},
+	}
+	for _, tc := range cases {
+		w, body := doJSON(r, http.MethodPost, tc.path, tc.body, nil)
+		if w.Code != http.StatusInternalServerError || body["error"] != tc.want {
+			t.Fatalf("%s: status %d: %s", tc.name, w.Code, w.Body)
+		}
+	}
+}
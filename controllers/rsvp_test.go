@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+)
+
+func TestRespondInviteHeadcountLimits(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	r := gin.New()
+	r.POST("/invite/:uuid/respond", ctrl.RespondInvite)
+
+	cases := []struct {
+		name   string
+		body   gin.H
+		status int
+	}{
+		{"dentro do limite", gin.H{"accepted": true, "adults": 1, "children": 1}, http.StatusOK},
+		{"adultos demais", gin.H{"accepted": true, "adults": 3}, http.StatusBadRequest},
+		{"sem adultos", gin.H{"accepted": true, "adults": 0, "children": 1}, http.StatusBadRequest},
+		{"soma que estouraria uint", gin.H{"accepted": true, "adults": 2, "children": uint64(math.MaxUint64)}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			invite := createInvite(t, ctrl.DB, event.ID, tc.name)
+			ctrl.DB.Model(&invite).Update("max_companions", 1)
+
+			w, _ := doJSON(r, http.MethodPost, "/invite/"+invite.UUID+"/respond", tc.body, nil)
+			if w.Code != tc.status {
+				t.Fatalf("status %d, esperado %d: %s", w.Code, tc.status, w.Body)
+			}
+
+			var saved models.EventInvited
+			ctrl.DB.First(&saved, invite.ID)
+			if tc.status != http.StatusOK && saved.Accepted != nil {
+				t.Fatalf("resposta inválida foi salva: %+v", saved)
+			}
+		})
+	}
+}
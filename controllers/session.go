@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+var (
+	errRefreshTokenInvalid = errors.New("Sessão expirada, faça login novamente")
+	errRefreshTokenReused  = errors.New("Sessão encerrada por segurança, faça login novamente")
+)
+
+// issueSession creates an access token and a refresh token for the user. An empty
+// familyID starts a new family, as on login; rotations keep the current one.
+func issueSession(db *gorm.DB, user models.User, familyID string) (gin.H, error) {
+	if familyID == "" {
+		id, err := utils.GenerateSecureToken(16)
+		if err != nil {
+			return nil, err
+		}
+		familyID = id
+	}
+
+	refresh, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refresh),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	access, err := utils.GenerateToken(user.ID, user.SessionVersion, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"token":         access,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
+		"refresh_token": refresh,
+	}, nil
+}
+
+// revokeUserSessions logs the user out everywhere: refresh tokens stop working and
+// the session version bump rejects the access tokens already issued.
+func revokeUserSessions(tx *gorm.DB, userID uint) error {
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.User{}).Where("id = ?", userID).
+		Update("session_version", gorm.Expr("session_version + 1")).Error
+}
+
+func revokeTokenFamily(db *gorm.DB, familyID string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RefreshSession swaps a refresh token for a new pair. Each refresh token works
+// once: presenting a rotated one means it leaked, so the whole family is revoked.
+func RefreshSession(c *gin.Context, db *gorm.DB) {
+	var input RefreshTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var session gin.H
+	var reusedFamily string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", utils.HashToken(input.RefreshToken)).
+			First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errRefreshTokenInvalid
+			}
+			return err
+		}
+		if token.RevokedAt != nil {
+			// Revoked outside this transaction, which is rolled back by the error.
+			reusedFamily = token.FamilyID
+			return errRefreshTokenReused
+		}
+		if token.ExpiresAt.Before(time.Now()) {
+			return errRefreshTokenInvalid
+		}
+
+		var user models.User
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			return errRefreshTokenInvalid
+		}
+		if err := tx.Model(&token).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		var err error
+		session, err = issueSession(tx, user, token.FamilyID)
+		return err
+	})
+
+	if reusedFamily != "" {
+		if err := revokeTokenFamily(db, reusedFamily); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível renovar a sessão"})
+			return
+		}
+	}
+	switch {
+	case errors.Is(err, errRefreshTokenReused):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "refresh_token_reused"})
+	case errors.Is(err, errRefreshTokenInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "refresh_token_invalid"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível renovar a sessão"})
+	default:
+		c.JSON(http.StatusOK, session)
+	}
+}
+
+// Logout ends the session of the access token, on this device only: its refresh
+// tokens are revoked, which also rejects the access tokens issued for it.
+func Logout(c *gin.Context, db *gorm.DB) {
+	if err := revokeTokenFamily(db, c.GetString("sessionID")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível encerrar a sessão"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sessão encerrada"})
+}
+
+func LogoutAll(c *gin.Context, db *gorm.DB) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return revokeUserSessions(tx, c.GetUint("userID"))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível encerrar as sessões"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Todas as sessões foram encerradas"})
+}
+
+// PruneRefreshTokens deletes the expired refresh tokens and the families that have
+// no usable token left. Revoked tokens of a live family are kept until they expire,
+// since presenting one of them is what reveals a leaked token.
+func PruneRefreshTokens(db *gorm.DB) (int64, error) {
+	now := time.Now()
+	expired := db.Unscoped().Where("expires_at <= ?", now).Delete(&models.RefreshToken{})
+	if expired.Error != nil {
+		return 0, expired.Error
+	}
+
+	live := db.Table("(?) AS live", db.Model(&models.RefreshToken{}).
+		Select("family_id").Where("revoked_at IS NULL AND expires_at > ?", now)).Select("family_id")
+	revoked := db.Unscoped().Where("family_id NOT IN (?)", live).Delete(&models.RefreshToken{})
+	if revoked.Error != nil {
+		return expired.RowsAffected, revoked.Error
+	}
+	return expired.RowsAffected + revoked.RowsAffected, nil
+}
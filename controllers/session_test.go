@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	middleware "github.com/pedroShimpa/cha-de-bebe-api/middlewares"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+)
+
+func sessionRouter(db *gorm.DB) *gin.Engine {
+	r := gin.New()
+	r.POST("/token/refresh", func(c *gin.Context) { RefreshSession(c, db) })
+	api := r.Group("/api", middleware.AuthMiddleware(db))
+	api.POST("/logout", func(c *gin.Context) { Logout(c, db) })
+	api.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func bearer(session gin.H) http.Header {
+	return http.Header{"Authorization": {"Bearer " + session["token"].(string)}}
+}
+
+func TestReusedRefreshTokenRevokesFamily(t *testing.T) {
+	db := newTestController(t).DB
+	user := createUser(t, db, "ana@example.com")
+	r := sessionRouter(db)
+
+	login, err := issueSession(db, user, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := issueSession(db, user, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, rotated := doJSON(r, http.MethodPost, "/token/refresh", gin.H{"refresh_token": login["refresh_token"]}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("rotação: status %d: %s", w.Code, w.Body)
+	}
+
+	// The first token was already rotated: whoever holds it now is not the client.
+	w, body := doJSON(r, http.MethodPost, "/token/refresh", gin.H{"refresh_token": login["refresh_token"]}, nil)
+	if w.Code != http.StatusUnauthorized || body["code"] != "refresh_token_reused" {
+		t.Fatalf("reuso: status %d: %s", w.Code, w.Body)
+	}
+	if w, _ := doJSON(r, http.MethodPost, "/token/refresh", gin.H{"refresh_token": rotated["refresh_token"]}, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("token rotado continuou válido: status %d", w.Code)
+	}
+	for name, session := range map[string]gin.H{"login": login, "rotação": rotated} {
+		if w, _ := doJSON(r, http.MethodGet, "/api/me", nil, bearer(session)); w.Code != http.StatusUnauthorized {
+			t.Fatalf("access token da %s continuou válido: status %d", name, w.Code)
+		}
+	}
+
+	// Other devices keep their own family.
+	if w, _ := doJSON(r, http.MethodGet, "/api/me", nil, bearer(other)); w.Code != http.StatusOK {
+		t.Fatalf("outra sessão encerrada: status %d", w.Code)
+	}
+	if w, _ := doJSON(r, http.MethodPost, "/token/refresh", gin.H{"refresh_token": other["refresh_token"]}, nil); w.Code != http.StatusOK {
+		t.Fatalf("outra sessão não renova: status %d", w.Code)
+	}
+}
+
+func TestLogoutEndsAccessToken(t *testing.T) {
+	db := newTestController(t).DB
+	user := createUser(t, db, "ana@example.com")
+	r := sessionRouter(db)
+
+	session, err := issueSession(db, user, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := issueSession(db, user, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w, _ := doJSON(r, http.MethodPost, "/api/logout", nil, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("logout sem token: status %d", w.Code)
+	}
+	if w, _ := doJSON(r, http.MethodPost, "/api/logout", nil, bearer(session)); w.Code != http.StatusOK {
+		t.Fatalf("logout: status %d: %s", w.Code, w.Body)
+	}
+	if w, _ := doJSON(r, http.MethodGet, "/api/me", nil, bearer(session)); w.Code != http.StatusUnauthorized {
+		t.Fatalf("access token válido após logout: status %d", w.Code)
+	}
+	if w, _ := doJSON(r, http.MethodPost, "/token/refresh", gin.H{"refresh_token": session["refresh_token"]}, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh token válido após logout: status %d", w.Code)
+	}
+	if w, _ := doJSON(r, http.MethodGet, "/api/me", nil, bearer(other)); w.Code != http.StatusOK {
+		t.Fatalf("logout encerrou outra sessão: status %d", w.Code)
+	}
+
+	noSession, _ := utils.GenerateToken(user.ID, user.SessionVersion, "")
+	header := http.Header{"Authorization": {"Bearer " + noSession}}
+	if w, _ := doJSON(r, http.MethodGet, "/api/me", nil, header); w.Code != http.StatusUnauthorized {
+		t.Fatalf("token sem sessão aceito: status %d", w.Code)
+	}
+}
+
+func TestPruneRefreshTokens(t *testing.T) {
+	db := newTestController(t).DB
+	user := createUser(t, db, "ana@example.com")
+	now := time.Now()
+	hour := time.Hour
+
+	tokens := map[string]models.RefreshToken{
+		"expired":        {FamilyID: "old", ExpiresAt: now.Add(-hour)},
+		"logged-out":     {FamilyID: "out", ExpiresAt: now.Add(hour), RevokedAt: &now},
+		"rotated":        {FamilyID: "live", ExpiresAt: now.Add(hour), RevokedAt: &now},
+		"current":        {FamilyID: "live", ExpiresAt: now.Add(hour)},
+		"rotated-single": {FamilyID: "stale", ExpiresAt: now.Add(hour), RevokedAt: &now},
+		"expired-single": {FamilyID: "stale", ExpiresAt: now.Add(-hour)},
+	}
+	for name, token := range tokens {
+		token.UserID = user.ID
+		token.TokenHash = utils.HashToken(name)
+		if err := db.Create(&token).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := PruneRefreshTokens(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []models.RefreshToken
+	db.Unscoped().Order("id").Find(&left)
+	if removed != 4 || len(left) != 2 {
+		t.Fatalf("removidos %d, restaram %+v", removed, left)
+	}
+	// Rotated tokens of a live family stay, so reusing them is still detected.
+	for _, token := range left {
+		if token.FamilyID != "live" {
+			t.Fatalf("família %q não removida", token.FamilyID)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+)
+
+type ReservationStatusInput struct {
+	Received     *bool `json:"received,omitempty"`
+	ThankYouSent *bool `json:"thank_you_sent,omitempty"`
+}
+
+type giftGiverRow struct {
+	Gift     string
+	Link     string
+	Guest    string
+	Amount   string
+	GivenAt  time.Time
+	Pledge   bool
+	Received bool
+	Thanked  bool
+}
+
+type giftPledgeRow struct {
+	InviteUUID  string
+	AmountCents int64
+	CreatedAt   time.Time
+	GiftName    string
+	GiftLink    string
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "Sim"
+	}
+	return "Não"
+}
+
+func toggleTimestamp(current *time.Time, set bool) *time.Time {
+	if !set {
+		return nil
+	}
+	if current != nil {
+		return current
+	}
+	now := time.Now()
+	return &now
+}
+
+func (ctrl *Controller) UpdateReservationStatus(c *gin.Context) {
+	reservationID := c.Param("reservation_id")
+
+	event, ok := ctrl.authorizeEvent(c, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	var reservation models.GiftReservation
+	if err := ctrl.DB.Joins("Gift").
+		Where("gift_reservations.id = ? AND Gift.event_id = ?", reservationID, event.ID).
+		First(&reservation).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reserva não encontrada"})
+		return
+	}
+
+	var input ReservationStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Received != nil {
+		reservation.ReceivedAt = toggleTimestamp(reservation.ReceivedAt, *input.Received)
+	}
+	if input.ThankYouSent != nil {
+		reservation.ThankedAt = toggleTimestamp(reservation.ThankedAt, *input.ThankYouSent)
+	}
+
+	if err := ctrl.DB.Model(&reservation).
+		Select("ReceivedAt", "ThankedAt").
+		Updates(&reservation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível atualizar a reserva"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reservation": reservation})
+}
+
+// giftGivers lists who reserved or contributed to each gift of the event, which
+// must be loaded with its Invited.
+func (ctrl *Controller) giftGivers(event models.Event) ([]giftGiverRow, error) {
+	var reservations []models.GiftReservation
+	if err := ctrl.DB.Joins("Gift").
+		Where("Gift.event_id = ?", event.ID).
+		Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+	var pledges []giftPledgeRow
+	if err := ctrl.DB.Model(&models.GiftPledge{}).
+		Select("gift_pledges.invite_uuid, gift_pledges.amount_cents, gift_pledges.created_at, event_gifts.name AS gift_name, event_gifts.link AS gift_link").
+		Joins("JOIN event_gifts ON event_gifts.id = gift_pledges.event_gift_id").
+		Where("event_gifts.event_id = ? AND event_gifts.deleted_at IS NULL", event.ID).
+		Scan(&pledges).Error; err != nil {
+		return nil, err
+	}
+
+	// Dates are shown as the organizer sees them, in the event's timezone.
+	loc, err := utils.LoadTimezone(event.Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+
+	guestNames := map[string]string{}
+	for _, inv := range event.Invited {
+		guestNames[inv.UUID] = inv.Name
+	}
+
+	givers := make([]giftGiverRow, 0, len(reservations)+len(pledges))
+	for _, r := range reservations {
+		givers = append(givers, giftGiverRow{
+			Gift:     r.Gift.Name,
+			Link:     r.Gift.Link,
+			Guest:    guestNames[r.InviteUUID],
+			GivenAt:  r.CreatedAt.In(loc),
+			Received: r.ReceivedAt != nil,
+			Thanked:  r.ThankedAt != nil,
+		})
+	}
+	for _, p := range pledges {
+		givers = append(givers, giftGiverRow{
+			Gift:    p.GiftName,
+			Link:    p.GiftLink,
+			Guest:   guestNames[p.InviteUUID],
+			Amount:  utils.FormatBRL(p.AmountCents),
+			GivenAt: p.CreatedAt.In(loc),
+			Pledge:  true,
+		})
+	}
+	sort.SliceStable(givers, func(i, j int) bool {
+		if givers[i].Gift != givers[j].Gift {
+			return givers[i].Gift < givers[j].Gift
+		}
+		return givers[i].GivenAt.Before(givers[j].GivenAt)
+	})
+	return givers, nil
+}
+
+func (ctrl *Controller) ExportGiftGivers(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleViewer, "Invited")
+	if !ok {
+		return
+	}
+
+	givers, err := ctrl.giftGivers(event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível carregar as reservas"})
+		return
+	}
+
+	rows := make([][]string, len(givers))
+	for i, g := range givers {
+		received, thanked := yesNo(g.Received), yesNo(g.Thanked)
+		if g.Pledge {
+			// Contributions are paid by Pix, there's nothing to mark as received.
+			received, thanked = "-", "-"
+		}
+		rows[i] = []string{g.Gift, g.Guest, g.Amount, g.GivenAt.Format(exportDateLayout), received, thanked, g.Link}
+	}
+	sendExport(c, c.Query("format"), exportTable{
+		Filename: fmt.Sprintf("presentes-evento-%d", event.ID),
+		Title:    "Quem deu o quê - " + event.Title,
+		Header:   []string{"Presente", "Convidado", "Valor", "Data", "Recebido", "Agradecimento enviado", "Link"},
+		Widths:   []float64{20, 18, 8, 10, 8, 10, 24},
+		Rows:     rows,
+	})
+}
+
+// GiftGiversLink returns a short-lived link to the printable list, which a
+// browser can open without the Authorization header.
+func (ctrl *Controller) GiftGiversLink(c *gin.Context) {
+	event, ok := ctrl.authorizeEvent(c, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := ctrl.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+		return
+	}
+	token, err := utils.GenerateExportToken(user.ID, event.ID, user.SessionVersion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível gerar o link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":        publicBaseURL(c) + "/exports/gift-givers?token=" + url.QueryEscape(token),
+		"expires_in": int(utils.ExportLinkTTL.Seconds()),
+	})
+}
+
+// ServeGiftGiversPage renders the printable list for a link from GiftGiversLink.
+// Access is checked again, so removed co-hosts can't use a link they kept.
+func (ctrl *Controller) ServeGiftGiversPage(c *gin.Context) {
+	denied := func() {
+		c.HTML(http.StatusUnauthorized, "gift_givers.html", gin.H{"error": "Link inválido ou expirado, gere um novo pelo aplicativo"})
+	}
+
+	userID, eventID, sessionVersion, err := utils.ParseExportToken(c.Query("token"))
+	if err != nil {
+		denied()
+		return
+	}
+	var user models.User
+	if err := ctrl.DB.First(&user, userID).Error; err != nil || user.SessionVersion != sessionVersion {
+		denied()
+		return
+	}
+	var event models.Event
+	if err := ctrl.DB.Preload("Invited").First(&event, eventID).Error; err != nil {
+		denied()
+		return
+	}
+	if _, ok := eventRole(ctrl.DB, event, user.ID); !ok {
+		denied()
+		return
+	}
+
+	givers, err := ctrl.giftGivers(event)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "gift_givers.html", gin.H{"error": "Não foi possível carregar as reservas"})
+		return
+	}
+	c.HTML(http.StatusOK, "gift_givers.html", gin.H{"event": event, "givers": givers})
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+)
+
+// seedGiftGivers creates an event with one reserved item and one fund contribution.
+func seedGiftGivers(t *testing.T, ctrl *Controller) (models.User, models.Event) {
+	t.Helper()
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	ana := createInvite(t, ctrl.DB, event.ID, "Ana")
+	bia := createInvite(t, ctrl.DB, event.ID, "Bia")
+
+	item := models.EventGift{EventID: event.ID, Name: "Carrinho", Kind: models.GiftItem, MaxReservations: 1}
+	fund := models.EventGift{EventID: event.ID, Name: "Fundo do berço", Kind: models.GiftFund, TargetCents: 50000}
+	ctrl.DB.Create(&item)
+	ctrl.DB.Create(&fund)
+	ctrl.DB.Create(&models.GiftReservation{EventGiftID: item.ID, InviteUUID: ana.UUID})
+	ctrl.DB.Create(&models.GiftPledge{EventGiftID: fund.ID, InviteUUID: bia.UUID, AmountCents: 15000})
+	return owner, event
+}
+
+func TestExportGiftGiversIncludesPledges(t *testing.T) {
+	ctrl := newTestController(t)
+	owner, event := seedGiftGivers(t, ctrl)
+	r := gin.New()
+	r.GET("/events/:id/gifts/export", withUser(owner.ID), ctrl.ExportGiftGivers)
+
+	req := httptest.NewRequest(http.MethodGet, "/events/"+fmt.Sprint(event.ID)+"/gifts/export", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	csv := w.Body.String()
+	for _, want := range []string{"Carrinho;Ana;", "Fundo do berço;Bia;R$ 150,00;"} {
+		if !strings.Contains(csv, want) {
+			t.Fatalf("exportação sem %q:\n%s", want, csv)
+		}
+	}
+}
+
+func TestGiftGiversLinkOpensInBrowser(t *testing.T) {
+	ctrl := newTestController(t)
+	owner, event := seedGiftGivers(t, ctrl)
+	stranger := createUser(t, ctrl.DB, "outra@example.com")
+
+	r := gin.New()
+	r.LoadHTMLGlob("../templates/*")
+	r.GET("/exports/gift-givers", ctrl.ServeGiftGiversPage)
+	r.POST("/as/:user/events/:id/gifts/export/link", func(c *gin.Context) {
+		id, _ := strconv.ParseUint(c.Param("user"), 10, 64)
+		c.Set("userID", uint(id))
+	}, ctrl.GiftGiversLink)
+
+	eventPath := "/events/" + fmt.Sprint(event.ID) + "/gifts/export/link"
+	w, _ := doJSON(r, http.MethodPost, "/as/"+fmt.Sprint(stranger.ID)+eventPath, nil, nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("não membro gerou link: status %d", w.Code)
+	}
+
+	w, body := doJSON(r, http.MethodPost, "/as/"+fmt.Sprint(owner.ID)+eventPath, nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	link, err := url.Parse(body["url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	open := func(rawQuery string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, link.Path+"?"+rawQuery, nil))
+		return w
+	}
+
+	page := open(link.RawQuery)
+	if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), "Bia") || !strings.Contains(page.Body.String(), "R$ 150,00") {
+		t.Fatalf("página: status %d\n%s", page.Code, page.Body)
+	}
+
+	access, _ := utils.GenerateToken(owner.ID, owner.SessionVersion, "")
+	if w := open("token=" + access); w.Code != http.StatusUnauthorized {
+		t.Fatalf("token de acesso abriu a exportação: status %d", w.Code)
+	}
+
+	// A password reset ends every session, links included.
+	ctrl.DB.Model(&owner).Update("session_version", owner.SessionVersion+1)
+	if w := open(link.RawQuery); w.Code != http.StatusUnauthorized {
+		t.Fatalf("link sobreviveu à troca de senha: status %d", w.Code)
+	}
+}
+
+func TestExportGiftGiversUsesEventTimezone(t *testing.T) {
+	ctrl := newTestController(t)
+	owner, event := seedGiftGivers(t, ctrl)
+	ctrl.DB.Model(&event).Update("timezone", "Asia/Tokyo")
+	givenAt := time.Date(2026, 11, 21, 22, 30, 0, 0, time.UTC)
+	ctrl.DB.Model(&models.GiftReservation{}).Where("1 = 1").Update("created_at", givenAt)
+	ctrl.DB.Model(&models.GiftPledge{}).Where("1 = 1").Update("created_at", givenAt)
+
+	r := gin.New()
+	r.GET("/events/:id/gifts/export", withUser(owner.ID), ctrl.ExportGiftGivers)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events/"+fmt.Sprint(event.ID)+"/gifts/export", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	// 22:30 UTC is already the next morning in Tokyo (UTC+9).
+	csv := w.Body.String()
+	for _, want := range []string{"Carrinho;Ana;;22/11/2026 07:30;", "Fundo do berço;Bia;R$ 150,00;22/11/2026 07:30;"} {
+		if !strings.Contains(csv, want) {
+			t.Fatalf("exportação sem %q:\n%s", want, csv)
+		}
+	}
+}
+
+func TestExportGiftGiversPDFOutsideLatin1(t *testing.T) {
+	ctrl := newTestController(t)
+	owner := createUser(t, ctrl.DB, "dona@example.com")
+	event := createEvent(t, ctrl.DB, owner.ID)
+	guest := createInvite(t, ctrl.DB, event.ID, "Ana 🎉 D’Ávila")
+	gift := models.EventGift{EventID: event.ID, Name: "Carrinho de bebê – “Premium” — 🍼", Kind: models.GiftItem, MaxReservations: 1}
+	ctrl.DB.Create(&gift)
+	ctrl.DB.Create(&models.GiftReservation{EventGiftID: gift.ID, InviteUUID: guest.UUID})
+
+	r := gin.New()
+	r.GET("/events/:id/gifts/export", withUser(owner.ID), ctrl.ExportGiftGivers)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events/"+fmt.Sprint(event.ID)+"/gifts/export?format=pdf", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "%PDF-") {
+		t.Fatalf("status %d: %.40q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/notifications"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	emailVerificationTTL = 48 * time.Hour
+	// mailCooldown is the minimum time between two e-mails of the same kind to a user.
+	mailCooldown = time.Minute
+)
+
+var errVerificationTokenInvalid = errors.New("Link de confirmação inválido ou expirado")
+
+// sendVerificationEmail replaces any pending link of the user with a new one and e-mails it.
+func sendVerificationEmail(db *gorm.DB, mailer notifications.Mailer, user models.User) error {
+	baseURL, err := emailBaseURL()
+	if err != nil {
+		return err
+	}
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.EmailVerification{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailVerification{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(emailVerificationTTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := notifications.Message{
+		To:      user.Email,
+		Subject: "Confirme seu e-mail",
+		Body: "Olá, " + user.NomeCompleto + "!\n\n" +
+			"Para confirmar seu e-mail e poder publicar seus eventos, acesse o link abaixo:\n" +
+			baseURL + "/email/verify?token=" + token + "\n\n" +
+			"O link vale por 48 horas.",
+	}
+	go func() {
+		if err := mailer.Send(msg); err != nil {
+			log.Printf("confirmação de e-mail do usuário %d: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// VerifyEmail is opened from the link in the e-mail, so it answers with a page.
+func VerifyEmail(c *gin.Context, db *gorm.DB) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var verification models.EmailVerification
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", utils.HashToken(c.Query("token")), time.Now()).
+			First(&verification).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errVerificationTokenInvalid
+			}
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&verification).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).
+			Where("id = ? AND verified_at IS NULL", verification.UserID).
+			Update("verified_at", now).Error; err != nil {
+			return err
+		}
+		// Co-host invites accepted before the e-mail was confirmed.
+		return tx.Model(&models.EventMember{}).
+			Where("user_id = ? AND accepted_at IS NULL AND token_hash IS NULL", verification.UserID).
+			Update("accepted_at", now).Error
+	})
+
+	switch {
+	case errors.Is(err, errVerificationTokenInvalid):
+		c.HTML(http.StatusBadRequest, "email_verified.html", gin.H{"ok": false, "message": err.Error()})
+	case err != nil:
+		c.HTML(http.StatusInternalServerError, "email_verified.html", gin.H{"ok": false, "message": "Não foi possível confirmar seu e-mail, tente novamente"})
+	default:
+		c.HTML(http.StatusOK, "email_verified.html", gin.H{"ok": true, "message": "E-mail confirmado! Você já pode publicar seus eventos."})
+	}
+}
+
+func ResendVerification(c *gin.Context, db *gorm.DB, mailer notifications.Mailer) {
+	var user models.User
+	if err := db.First(&user, c.GetUint("userID")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+		return
+	}
+	if user.VerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Seu e-mail já está confirmado"})
+		return
+	}
+
+	if wait := mailCooldownLeft(db, &models.EmailVerification{}, user.ID); wait > 0 {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":               "Aguarde um pouco antes de pedir outro e-mail",
+			"retry_after_seconds": int(wait.Seconds()) + 1,
+		})
+		return
+	}
+
+	if err := sendVerificationEmail(db, mailer, user); err != nil {
+		log.Printf("confirmação de e-mail do usuário %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível enviar o e-mail de confirmação"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Enviamos um novo link de confirmação para " + user.Email})
+}
+
+// mailCooldownLeft is how long the user still has to wait before another link of
+// this kind (a token model with UserID) is e-mailed. Replaced links are
+// soft-deleted, so they still count.
+func mailCooldownLeft(db *gorm.DB, model interface{}, userID uint) time.Duration {
+	var sent []time.Time
+	db.Unscoped().Model(model).Where("user_id = ?", userID).
+		Order("created_at DESC").Limit(1).Pluck("created_at", &sent)
+	if len(sent) == 0 {
+		return 0
+	}
+	return mailCooldown - time.Since(sent[0])
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/internal/testutil"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/notifications"
+)
+
+func TestRegisterSendsVerificationLinkOverSMTP(t *testing.T) {
+	db := newTestController(t).DB
+	server := testutil.StartSMTP(t)
+	mailer := notifications.SMTPMailer{Host: server.Host, Port: server.Port, From: "nao-responda@example.com"}
+
+	r := gin.New()
+	r.LoadHTMLGlob("../templates/*")
+	r.POST("/register", func(c *gin.Context) { Register(c, db, mailer) })
+	r.GET("/email/verify", func(c *gin.Context) { VerifyEmail(c, db) })
+
+	w, _ := doJSON(r, http.MethodPost, "/register", gin.H{"nome_completo": "Ana", "email": "ana@example.com", "senha": "segredo"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cadastro: status %d: %s", w.Code, w.Body)
+	}
+
+	msg := server.WaitForMessages(t, 1)[0]
+	if msg.From != "nao-responda@example.com" || len(msg.To) != 1 || msg.To[0] != "ana@example.com" {
+		t.Fatalf("envelope inesperado: %+v", msg)
+	}
+	m := mailTokenRegex.FindStringSubmatch(msg.Data)
+	if !strings.Contains(msg.Data, "https://cha.example.com/email/verify?token=") || m == nil {
+		t.Fatalf("e-mail sem link de confirmação:\n%s", msg.Data)
+	}
+
+	if w, _ := doJSON(r, http.MethodGet, "/email/verify?token="+m[1], nil, nil); w.Code != http.StatusOK {
+		t.Fatalf("confirmação: status %d", w.Code)
+	}
+	var user models.User
+	db.Where("email = ?", "ana@example.com").First(&user)
+	if user.VerifiedAt == nil {
+		t.Fatal("e-mail não confirmado pelo link")
+	}
+}
+
+func TestRegisterRequiresAppURL(t *testing.T) {
+	db := newTestController(t).DB
+	mailer := &notifications.MemoryMailer{}
+	t.Setenv("APP_URL", "")
+
+	r := gin.New()
+	r.POST("/register", func(c *gin.Context) { Register(c, db, mailer) })
+	w, _ := doJSON(r, http.MethodPost, "/register", gin.H{"nome_completo": "Ana", "email": "ana@example.com", "senha": "segredo"}, nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var users int64
+	db.Model(&models.User{}).Count(&users)
+	if users != 0 || len(mailer.Sent()) != 0 {
+		t.Fatalf("%d usuário(s) criados e %d e-mail(s) enviados sem APP_URL", users, len(mailer.Sent()))
+	}
+}
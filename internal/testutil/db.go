@@ -0,0 +1,60 @@
+// Package testutil holds the database and SMTP stand-ins shared by the tests.
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// OpenDB returns a migrated, empty database. It uses the MySQL server in
+// TEST_DB_DSN when set, so row locks are exercised for real, and a throwaway
+// SQLite file otherwise, where transactions are serialized instead.
+func OpenDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	config := &gorm.Config{TranslateError: true, Logger: logger.Default.LogMode(logger.Silent)}
+
+	var db *gorm.DB
+	var err error
+	if dsn := os.Getenv("TEST_DB_DSN"); dsn != "" {
+		db, err = gorm.Open(mysql.Open(dsn), config)
+		if err == nil {
+			err = dropTables(db)
+		}
+	} else {
+		path := filepath.Join(t.TempDir(), "test.db")
+		db, err = gorm.Open(sqlite.Open("file:"+path+"?_pragma=busy_timeout(10000)&_txlock=immediate"), config)
+	}
+	if err != nil {
+		t.Fatalf("abrindo banco de testes: %v", err)
+	}
+	if err := models.Migrate(db); err != nil {
+		t.Fatalf("migrando banco de testes: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("abrindo banco de testes: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func dropTables(db *gorm.DB) error {
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return err
+	}
+	names := make([]interface{}, len(tables))
+	for i, table := range tables {
+		names[i] = table
+	}
+	return db.Migrator().DropTable(names...)
+}
@@ -0,0 +1,120 @@
+package testutil
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// SMTPMessage is one e-mail received by the SMTP stand-in.
+type SMTPMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// SMTPServer speaks just enough SMTP for net/smtp.SendMail, without TLS or AUTH,
+// and keeps every message it receives.
+type SMTPServer struct {
+	Host string
+	Port string
+
+	mu       sync.Mutex
+	messages []SMTPMessage
+}
+
+// StartSMTP listens on a random local port until the test ends.
+func StartSMTP(t testing.TB) *SMTPServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("abrindo servidor SMTP de testes: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	s := &SMTPServer{Host: host, Port: port}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *SMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	var msg SMTPMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg = SMTPMessage{From: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.To = append(msg.To, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// WaitForMessages waits until n messages arrived, since they're usually sent in
+// the background, and returns them.
+func (s *SMTPServer) WaitForMessages(t testing.TB, n int) []SMTPMessage {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		messages := append([]SMTPMessage(nil), s.messages...)
+		s.mu.Unlock()
+		if len(messages) >= n {
+			return messages
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("esperava %d e-mail(s) no servidor SMTP, recebidos %d", n, len(messages))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -3,9 +3,12 @@ package main
 import (
 	"log"
 	"os"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/pedroShimpa/cha-de-bebe-api/controllers"
 	"github.com/pedroShimpa/cha-de-bebe-api/models"
 	"github.com/pedroShimpa/cha-de-bebe-api/routes"
 	"gorm.io/driver/mysql"
@@ -20,17 +23,16 @@ func main() {
 	dbDSN := os.Getenv("DB_DSN")
 	port := os.Getenv("PORT")
 
-	db, err := gorm.Open(mysql.Open(dbDSN), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dbDSN), &gorm.Config{TranslateError: true})
 	if err != nil {
 		panic("falha ao conectar ao banco de dados")
 	}
 
-	db.AutoMigrate(&models.User{})
-	db.AutoMigrate(&models.UserProfile{})
-	db.AutoMigrate(&models.Event{})
-	db.AutoMigrate(&models.EventGift{})
-	db.AutoMigrate(&models.EventInvited{})
-	db.AutoMigrate(&models.GiftReservation{})
+	if err := models.Migrate(db); err != nil {
+		log.Fatalf("falha ao migrar o banco de dados: %v", err)
+	}
+
+	go pruneSessions(db)
 
 	r := gin.Default()
 	routes.SetupRoutes(r, db)
@@ -41,3 +43,15 @@ func main() {
 
 	r.Run(":" + port)
 }
+
+// pruneSessions keeps the refresh_tokens table from growing with every login and
+// rotation.
+func pruneSessions(db *gorm.DB) {
+	for ; ; time.Sleep(time.Hour) {
+		if n, err := controllers.PruneRefreshTokens(db); err != nil {
+			log.Printf("limpando sessões expiradas: %v", err)
+		} else if n > 0 {
+			log.Printf("%d tokens de sessão removidos", n)
+		}
+	}
+}
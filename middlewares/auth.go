@@ -3,14 +3,16 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
 )
 
-var JwtSecret = []byte("segredo_super_secreto")
-
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -21,8 +23,8 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return JwtSecret, nil
-		})
+			return utils.JwtSecret, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido"})
@@ -30,14 +32,39 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Link tokens (e.g. exports) carry a purpose and only work on their own route.
 		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
+		if !ok || claims["purpose"] != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido"})
 			c.Abort()
 			return
 		}
 
-		c.Set("userID", uint(claims["user_id"].(float64)))
+		userID, _ := claims["user_id"].(float64)
+		sessionVersion, _ := claims["sv"].(float64)
+		sessionID, _ := claims["sid"].(string)
+
+		// Tokens issued before a password reset carry an old session version.
+		var user models.User
+		if err := db.Select("id", "session_version").First(&user, uint(userID)).Error; err != nil ||
+			user.SessionVersion != uint(sessionVersion) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Sessão expirada, faça login novamente"})
+			c.Abort()
+			return
+		}
+
+		// Logging out revokes the session's refresh tokens, which ends its access tokens too.
+		var active int64
+		if err := db.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, user.ID, time.Now()).
+			Count(&active).Error; err != nil || active == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Sessão expirada, faça login novamente"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", user.ID)
+		c.Set("sessionID", sessionID)
 
 		c.Next()
 	}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type DiaperSize string
+
+const (
+	DiaperRN DiaperSize = "RN"
+	DiaperP  DiaperSize = "P"
+	DiaperM  DiaperSize = "M"
+	DiaperG  DiaperSize = "G"
+	DiaperXG DiaperSize = "XG"
+)
+
+var DiaperSizes = []DiaperSize{DiaperRN, DiaperP, DiaperM, DiaperG, DiaperXG}
+
+func (s DiaperSize) Valid() bool {
+	for _, size := range DiaperSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+type EventDiaperTarget struct {
+	gorm.Model
+	EventID     uint       `json:"event_id" gorm:"not null;uniqueIndex:idx_event_diaper_size"`
+	Size        DiaperSize `json:"size" gorm:"not null;size:8;uniqueIndex:idx_event_diaper_size"`
+	TargetPacks uint       `json:"target_packs" gorm:"not null"`
+}
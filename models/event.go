@@ -1,6 +1,7 @@
 package models
 
 import (
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,20 +16,40 @@ const (
 
 type Event struct {
 	gorm.Model
-	UserID uint `json:"type" gorm:"not null"`
+	UserID uint `json:"user_id" gorm:"not null"`
 
 	Image string `json:"image" gorm:"null"`
 
+	Status             EventStatus `json:"status" gorm:"size:16;not null;default:published;index"`
+	StatusChangedAt    *time.Time  `json:"status_changed_at,omitempty"`
+	CancellationReason string      `json:"cancellation_reason,omitempty" gorm:"type:text"`
+
 	Type        EventType `json:"type" gorm:"not null"`
 	Title       string    `json:"title" gorm:"not null"`
 	Description string    `json:"description,omitempty" gorm:"type:text"`
 	PixKey      string    `json:"pix_key,omitempty"`
+	PixName     string    `json:"pix_name,omitempty"`
+	PixCity     string    `json:"pix_city,omitempty"`
+
+	StartsAt time.Time  `json:"starts_at" gorm:"index"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	Timezone string     `json:"timezone" gorm:"size:64;not null;default:America/Sao_Paulo"`
+	Address  string     `json:"address" gorm:"not null"`
 
-	EventDate string  `json:"event_date" gorm:"not null"`
-	HourStart string  `json:"hour_start" gorm:"not null"`
-	HourEnd    string `json:"hour_end,omitempty"`
-	Address   string     `json:"address" gorm:"not null"`
+	RSVPDeadline        *time.Time `json:"rsvp_deadline,omitempty"`
+	ReservationDeadline *time.Time `json:"reservation_deadline,omitempty"`
 
 	Invited []EventInvited `gorm:"foreignKey:EventID"`
+	Groups  []InviteGroup  `json:"groups,omitempty" gorm:"foreignKey:EventID"`
 	Gifts   []EventGift    `gorm:"foreignKey:EventID"`
 }
+
+// RSVPOpen reports whether guests can still answer the invite at the given time.
+func (e Event) RSVPOpen(now time.Time) bool {
+	return e.Status.AcceptsRSVP() && (e.RSVPDeadline == nil || now.Before(*e.RSVPDeadline))
+}
+
+// ReservationsOpen reports whether guests can still reserve or change gifts at the given time.
+func (e Event) ReservationsOpen(now time.Time) bool {
+	return e.Status.AcceptsReservations() && (e.ReservationDeadline == nil || now.Before(*e.ReservationDeadline))
+}
@@ -4,11 +4,23 @@ import (
 	"gorm.io/gorm"
 )
 
+type GiftKind string
+
+const (
+	GiftItem GiftKind = "item"
+	GiftFund GiftKind = "fundo"
+)
+
 type EventGift struct {
 	gorm.Model
 	EventID         uint              `json:"event_id" gorm:"not null;index"`
 	Name            string            `json:"name" gorm:"not null"`
 	Link            string            `json:"link,omitempty"`
+	Kind            GiftKind          `json:"kind" gorm:"not null;default:item"`
 	MaxReservations uint              `json:"max_reservations" gorm:"default:1"`
+	TargetCents     int64             `json:"target_cents,omitempty"`
+	PledgedCents    int64             `json:"pledged_cents" gorm:"not null;default:0"`
 	Reservations    []GiftReservation `gorm:"foreignKey:EventGiftID"`
+	Pledges         []GiftPledge      `json:"-" gorm:"foreignKey:EventGiftID"`
+	Version         uint              `json:"version" gorm:"not null;default:1"`
 }
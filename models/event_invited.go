@@ -8,12 +8,22 @@ import (
 
 type EventInvited struct {
 	gorm.Model
-	EventID     uint       `json:"event_id" gorm:"not null;index"`
-	UserID      *uint      `json:"user_id,omitempty"`
-	Name        string     `json:"name" gorm:"not null"`
-	Accepted    *bool      `json:"accepted,omitempty"`
-	UUID        string     `json:"uuid" gorm:"unique;not null"`
-	RespondedAt *time.Time `json:"responded_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	EventID     uint        `json:"event_id" gorm:"not null;index"`
+	GroupID     *uint       `json:"group_id,omitempty" gorm:"index"`
+	UserID      *uint       `json:"user_id,omitempty"`
+	Name        string      `json:"name" gorm:"not null"`
+	Whatsapp    string      `json:"whatsapp,omitempty"`
+	Email       string      `json:"email,omitempty"`
+	Accepted    *bool       `json:"accepted,omitempty"`
+	UUID        string      `json:"uuid" gorm:"unique;not null"`
+	RespondedAt *time.Time  `json:"responded_at,omitempty"`
+	DiaperSize  *DiaperSize `json:"diaper_size,omitempty" gorm:"size:8"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+
+	MaxCompanions uint               `json:"max_companions" gorm:"not null;default:0"`
+	Adults        uint               `json:"adults" gorm:"not null;default:0"`
+	Children      uint               `json:"children" gorm:"not null;default:0"`
+	Companions    []InvitedCompanion `json:"companions,omitempty" gorm:"foreignKey:EventInvitedID"`
+	Version       uint               `json:"version" gorm:"not null;default:1"`
 }
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type EventRole string
+
+const (
+	RoleViewer EventRole = "viewer"
+	RoleEditor EventRole = "editor"
+	RoleOwner  EventRole = "owner"
+)
+
+var roleLevels = map[EventRole]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+func (r EventRole) Valid() bool {
+	return roleLevels[r] > 0
+}
+
+// Allows reports whether this role includes everything the required role can do.
+func (r EventRole) Allows(required EventRole) bool {
+	return roleLevels[r] >= roleLevels[required]
+}
+
+// EventMember is a co-host of an event. The owner is always Event.UserID; invited
+// members stay pending, with UserID empty, until they open the link e-mailed to them.
+type EventMember struct {
+	gorm.Model
+	EventID    uint       `json:"event_id" gorm:"not null;uniqueIndex:idx_event_member_email"`
+	UserID     *uint      `json:"user_id,omitempty" gorm:"index"`
+	Email      string     `json:"email" gorm:"size:191;not null;uniqueIndex:idx_event_member_email"`
+	Role       EventRole  `json:"role" gorm:"size:16;not null"`
+	InvitedBy  uint       `json:"invited_by"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+
+	// TokenHash identifies the pending invitation link and is cleared on accept.
+	TokenHash       *string    `json:"-" gorm:"size:64;uniqueIndex"`
+	InviteExpiresAt *time.Time `json:"invite_expires_at,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+type EventStatus string
+
+const (
+	EventDraft      EventStatus = "draft"
+	EventPublished  EventStatus = "published"
+	EventRSVPClosed EventStatus = "rsvp_closed"
+	EventFinished   EventStatus = "finished"
+	EventCancelled  EventStatus = "cancelled"
+)
+
+var eventTransitions = map[EventStatus][]EventStatus{
+	EventDraft:      {EventPublished, EventCancelled},
+	EventPublished:  {EventRSVPClosed, EventFinished, EventCancelled},
+	EventRSVPClosed: {EventPublished, EventFinished, EventCancelled},
+}
+
+func (s EventStatus) CanTransitionTo(next EventStatus) bool {
+	for _, allowed := range eventTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsRSVP reports whether guests can still confirm or decline.
+func (s EventStatus) AcceptsRSVP() bool {
+	return s == EventPublished
+}
+
+// AcceptsReservations reports whether guests can still pick or change gifts;
+// closing RSVPs doesn't stop gift reservations.
+func (s EventStatus) AcceptsReservations() bool {
+	return s == EventPublished || s == EventRSVPClosed
+}
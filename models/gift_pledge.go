@@ -0,0 +1,12 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type GiftPledge struct {
+	gorm.Model
+	EventGiftID uint   `json:"event_gift_id" gorm:"not null;index"`
+	InviteUUID  string `json:"invite_uuid" gorm:"not null;index"`
+	AmountCents int64  `json:"amount_cents" gorm:"not null"`
+}
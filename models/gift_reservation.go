@@ -1,11 +1,16 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type GiftReservation struct {
 	gorm.Model
-	EventGiftID uint   `json:"event_gift_id" gorm:"not null;index"`
-	InviteUUID  string `json:"invite_uuid" gorm:"not null;index"`
+	EventGiftID uint       `json:"event_gift_id" gorm:"not null;uniqueIndex:idx_gift_invite"`
+	InviteUUID  string     `json:"invite_uuid" gorm:"not null;index;uniqueIndex:idx_gift_invite"`
+	Gift        *EventGift `json:"gift,omitempty" gorm:"foreignKey:EventGiftID"`
+	ReceivedAt  *time.Time `json:"received_at,omitempty"`
+	ThankedAt   *time.Time `json:"thanked_at,omitempty"`
 }
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type InviteGroup struct {
+	gorm.Model
+	EventID uint           `json:"event_id" gorm:"not null;index"`
+	Name    string         `json:"name" gorm:"not null"`
+	UUID    string         `json:"uuid" gorm:"unique;not null"`
+	Members []EventInvited `json:"members" gorm:"foreignKey:GroupID"`
+}
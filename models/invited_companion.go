@@ -0,0 +1,11 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type InvitedCompanion struct {
+	gorm.Model
+	EventInvitedID uint   `json:"event_invited_id" gorm:"not null;index"`
+	Name           string `json:"name" gorm:"not null"`
+}
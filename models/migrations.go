@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+)
+
+// Migrate creates or updates every table, running the data migrations that
+// depend on the previous schema before it goes away.
+func Migrate(db *gorm.DB) error {
+	if err := MigrateUserVerification(db); err != nil {
+		return fmt.Errorf("verificação de e-mail: %w", err)
+	}
+	if err := db.AutoMigrate(&PasswordReset{}, &EmailVerification{}, &RefreshToken{}, &UserProfile{}, &EventMember{}, &Event{}); err != nil {
+		return err
+	}
+	if err := MigrateEventSchedule(db); err != nil {
+		return fmt.Errorf("datas dos eventos: %w", err)
+	}
+	if err := db.AutoMigrate(&EventGift{}, &InviteGroup{}); err != nil {
+		return err
+	}
+	if err := MigrateInviteHeadcount(db); err != nil {
+		return fmt.Errorf("confirmações dos convites: %w", err)
+	}
+	return db.AutoMigrate(&GiftReservation{}, &GiftPledge{}, &EventDiaperTarget{}, &InvitedCompanion{})
+}
+
+// MigrateInviteHeadcount adds adults/children to the invites and counts every
+// guest who accepted before the headcount existed as one adult.
+func MigrateInviteHeadcount(db *gorm.DB) error {
+	m := db.Migrator()
+	backfill := m.HasTable(&EventInvited{}) && !m.HasColumn(&EventInvited{}, "Adults")
+	if err := db.AutoMigrate(&EventInvited{}); err != nil {
+		return err
+	}
+	if !backfill {
+		return nil
+	}
+	return db.Model(&EventInvited{}).Where("accepted = ? AND adults = 0", true).Update("adults", 1).Error
+}
+
+// MigrateEventSchedule moves events created before starts_at/ends_at existed off
+// the old free-form event_date/hour_start/hour_end columns, then drops them. A row
+// that can't be converted aborts the migration with the table untouched.
+func MigrateEventSchedule(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasColumn(&Event{}, "event_date") {
+		return nil
+	}
+
+	var rows []struct {
+		ID        uint
+		EventDate string
+		HourStart string
+		HourEnd   string
+	}
+	if err := db.Table("events").Select("id, event_date, hour_start, hour_end").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	// Nothing is converted unless every row is, since the old columns are dropped next.
+	var failed []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			start, end, err := utils.ParseEventSchedule(utils.EventSchedule{
+				Date:      row.EventDate,
+				HourStart: row.HourStart,
+				HourEnd:   row.HourEnd,
+				// The old columns had no end date, so an earlier end hour
+				// could only mean the party went past midnight.
+				Overnight: true,
+				Timezone:  utils.DefaultTimezone,
+			})
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("evento %d (%q %q %q): %v", row.ID, row.EventDate, row.HourStart, row.HourEnd, err))
+				continue
+			}
+			if err := tx.Table("events").Where("id = ?", row.ID).Updates(map[string]interface{}{
+				"starts_at": start,
+				"ends_at":   end,
+				"timezone":  utils.DefaultTimezone,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("corrija as datas e reinicie: %s", strings.Join(failed, "; "))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, column := range []string{"event_date", "hour_start", "hour_end"} {
+		if err := m.DropColumn(&Event{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateUserVerification adds verified_at to users and marks the accounts created
+// before e-mail verification existed as verified, so they can keep publishing.
+func MigrateUserVerification(db *gorm.DB) error {
+	m := db.Migrator()
+	backfill := m.HasTable(&User{}) && !m.HasColumn(&User{}, "VerifiedAt")
+	if err := db.AutoMigrate(&User{}); err != nil {
+		return err
+	}
+	if !backfill {
+		return nil
+	}
+	return db.Model(&User{}).Where("verified_at IS NULL").Update("verified_at", gorm.Expr("created_at")).Error
+}
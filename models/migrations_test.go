@@ -0,0 +1,123 @@
+package models_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// legacyInvite is event_inviteds as it was before adults/children existed.
+type legacyInvite struct {
+	ID       uint
+	EventID  uint
+	Name     string
+	UUID     string
+	Accepted *bool
+}
+
+func (legacyInvite) TableName() string { return "event_inviteds" }
+
+func openLegacyDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "legacy.db")),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMigrateInviteHeadcountBackfillsAccepted(t *testing.T) {
+	db := openLegacyDB(t)
+	if err := db.AutoMigrate(&legacyInvite{}); err != nil {
+		t.Fatal(err)
+	}
+	yes, no := true, false
+	db.Create(&[]legacyInvite{
+		{EventID: 1, Name: "Sim", UUID: "a", Accepted: &yes},
+		{EventID: 1, Name: "Não", UUID: "b", Accepted: &no},
+		{EventID: 1, Name: "Pendente", UUID: "c"},
+	})
+
+	if err := models.MigrateInviteHeadcount(db); err != nil {
+		t.Fatal(err)
+	}
+
+	var adults []uint
+	db.Model(&models.EventInvited{}).Order("id").Pluck("adults", &adults)
+	if len(adults) != 3 || adults[0] != 1 || adults[1] != 0 || adults[2] != 0 {
+		t.Fatalf("adults após a migração: %v", adults)
+	}
+
+	// Running again must not touch guests who answered since.
+	db.Model(&models.EventInvited{}).Where("uuid = ?", "a").Update("adults", 3)
+	if err := models.MigrateInviteHeadcount(db); err != nil {
+		t.Fatal(err)
+	}
+	var first models.EventInvited
+	db.Where("uuid = ?", "a").First(&first)
+	if first.Adults != 3 {
+		t.Fatalf("migração repetida alterou adults: %d", first.Adults)
+	}
+}
+
+// legacyEventsDB has an events table still carrying the free-form schedule columns.
+func legacyEventsDB(t *testing.T, rows [][3]string) *gorm.DB {
+	t.Helper()
+	db := openLegacyDB(t)
+	if err := db.AutoMigrate(&models.Event{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range []string{"event_date", "hour_start", "hour_end"} {
+		if err := db.Exec("ALTER TABLE events ADD COLUMN `" + column + "` TEXT").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, row := range rows {
+		if err := db.Exec("INSERT INTO events (id, user_id, title, address, status, type, starts_at, event_date, hour_start, hour_end) VALUES (?, 1, 'Chá', 'Rua A', 'published', 'not_defined', ?, ?, ?, ?)",
+			i+1, time.Time{}, row[0], row[1], row[2]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestMigrateEventScheduleConvertsOvernightParties(t *testing.T) {
+	db := legacyEventsDB(t, [][3]string{{"21/11/2026", "20:00", "01:00"}})
+	if err := models.MigrateEventSchedule(db); err != nil {
+		t.Fatal(err)
+	}
+
+	var event models.Event
+	db.First(&event, 1)
+	if event.EndsAt == nil || event.EndsAt.Sub(event.StartsAt) != 5*time.Hour {
+		t.Fatalf("início %v, término %v", event.StartsAt, event.EndsAt)
+	}
+	if db.Migrator().HasColumn(&models.Event{}, "event_date") {
+		t.Fatal("colunas antigas não foram removidas")
+	}
+}
+
+func TestMigrateEventScheduleAbortsOnUnparsableRow(t *testing.T) {
+	db := legacyEventsDB(t, [][3]string{
+		{"21/11/2026", "15:00", "18:00"},
+		{"sábado que vem", "15:00", ""},
+	})
+	if err := models.MigrateEventSchedule(db); err == nil {
+		t.Fatal("migração ignorou a data inválida")
+	}
+
+	if !db.Migrator().HasColumn(&models.Event{}, "event_date") {
+		t.Fatal("colunas antigas foram removidas apesar do erro")
+	}
+	var converted int64
+	db.Model(&models.Event{}).Where("starts_at > ?", time.Time{}).Count(&converted)
+	if converted != 0 {
+		t.Fatalf("%d evento(s) convertidos antes de abortar", converted)
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is one link of a rotation chain; every token issued from the same
+// login shares FamilyID, so presenting an already rotated token revokes them all.
+// Only the hash of the token is stored.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint       `gorm:"not null;index"`
+	FamilyID  string     `gorm:"size:32;not null;index"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	RevokedAt *time.Time `gorm:"index"`
+}
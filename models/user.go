@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -11,4 +13,10 @@ type User struct {
 	Whatsapp      string `json:"whatsapp" gorm:"null"`
 	FirabaseToken string `json:"firebase_token" gorm:"null"`
 	Senha         string `json:"senha" gorm:"not null"`
+	CalendarToken string `json:"-" gorm:"size:64;index"`
+
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+
+	// SessionVersion is embedded in issued tokens; bumping it logs out every session.
+	SessionVersion uint `json:"-" gorm:"not null;default:0"`
 }
@@ -0,0 +1,107 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional e-mails such as password reset links.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// SMTPMailer sends through a regular SMTP server, authenticating when Username is set.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// MailerFromEnv uses SMTP when SMTP_HOST is configured and only logs otherwise.
+// Bodies carry live reset and confirmation links, so they're only logged when
+// APP_ENV is development.
+func MailerFromEnv() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return LogMailer{ShowBody: os.Getenv("APP_ENV") == "development"}
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	return SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USER"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+func (m SMTPMailer) Send(msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	headers := []string{
+		"From: " + m.From,
+		"To: " + msg.To,
+		"Subject: " + mime.QEncoding.Encode("UTF-8", msg.Subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + msg.Body
+
+	if err := smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{msg.To}, []byte(body)); err != nil {
+		return fmt.Errorf("enviando e-mail para %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// LogMailer only logs the e-mails, useful until SMTP is configured. The body is
+// left out unless ShowBody is set.
+type LogMailer struct {
+	ShowBody bool
+}
+
+func (m LogMailer) Send(msg Message) error {
+	if !m.ShowBody {
+		log.Printf("e-mail para %s: %s (conteúdo omitido, configure SMTP_HOST para enviar)", msg.To, msg.Subject)
+		return nil
+	}
+	log.Printf("e-mail para %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// MemoryMailer keeps the e-mails in memory so tests can inspect what was sent.
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (m *MemoryMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *MemoryMailer) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
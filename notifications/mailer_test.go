@@ -0,0 +1,29 @@
+package notifications
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLogMailerRedactsBody(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	msg := Message{To: "ana@example.com", Subject: "Redefinição de senha", Body: "https://app/password/reset?token=segredo"}
+	if err := (LogMailer{}).Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "segredo") || !strings.Contains(buf.String(), "ana@example.com") {
+		t.Fatalf("log: %s", buf.String())
+	}
+
+	buf.Reset()
+	(LogMailer{ShowBody: true}).Send(msg)
+	if !strings.Contains(buf.String(), "segredo") {
+		t.Fatalf("corpo omitido em desenvolvimento: %s", buf.String())
+	}
+}
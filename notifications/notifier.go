@@ -0,0 +1,24 @@
+package notifications
+
+import (
+	"log"
+
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+)
+
+// Notifier is called when something guests need to know about happens to an event.
+type Notifier interface {
+	EventStatusChanged(event models.Event, from models.EventStatus, guests []models.EventInvited)
+	GiftRemoved(event models.Event, gift models.EventGift, guests []models.EventInvited)
+}
+
+// LogNotifier only logs the notifications, useful until a real channel is configured.
+type LogNotifier struct{}
+
+func (LogNotifier) EventStatusChanged(event models.Event, from models.EventStatus, guests []models.EventInvited) {
+	log.Printf("evento %d: status %s -> %s, %d convidado(s) a notificar", event.ID, from, event.Status, len(guests))
+}
+
+func (LogNotifier) GiftRemoved(event models.Event, gift models.EventGift, guests []models.EventInvited) {
+	log.Printf("evento %d: presente %q removido, %d convidado(s) tinham reserva ou contribuição", event.ID, gift.Name, len(guests))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/pedroShimpa/cha-de-bebe-api/controllers"
 	"github.com/pedroShimpa/cha-de-bebe-api/middlewares"
 	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/notifications"
 	"gorm.io/gorm"
 	"time"
 )
@@ -20,36 +21,75 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB) {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	ctrl := controllers.Controller{DB: db}
+	mailer := notifications.MailerFromEnv()
+	ctrl := controllers.Controller{DB: db, Notifier: notifications.LogNotifier{}, Mailer: mailer}
 	r.LoadHTMLGlob("templates/*")
-	r.POST("/register", func(c *gin.Context) { controllers.Register(c, db) })
+	r.POST("/register", func(c *gin.Context) { controllers.Register(c, db, mailer) })
 	r.POST("/login", func(c *gin.Context) { controllers.Login(c, db) })
+	r.POST("/token/refresh", func(c *gin.Context) { controllers.RefreshSession(c, db) })
+	r.POST("/password/forgot", func(c *gin.Context) { controllers.ForgotPassword(c, db, mailer) })
+	r.POST("/password/reset", func(c *gin.Context) { controllers.ResetPassword(c, db) })
+	r.GET("/password/reset", controllers.ServeResetPage)
+	r.GET("/email/verify", func(c *gin.Context) { controllers.VerifyEmail(c, db) })
+	r.GET("/members/accept", ctrl.ServeMemberInvitePage)
+	r.POST("/members/accept", ctrl.AcceptMemberInvite)
 	inviteCtrl := controllers.InvitePageController{}
 	r.GET("/invite", inviteCtrl.ServePage)
 	r.GET("/invites/:uuid/event", ctrl.GetEventByInvite)
 	r.POST("/invites/:uuid/respond", ctrl.RespondInvite)
+	r.GET("/invites/:uuid/pix", ctrl.GetInvitePix)
+	r.GET("/invites/:uuid/event.ics", ctrl.GetInviteICS)
+	r.GET("/calendar/:token/events.ics", ctrl.GetCalendarFeed)
+	r.GET("/exports/gift-givers", ctrl.ServeGiftGiversPage)
+	r.GET("/groups/:uuid/event", ctrl.GetEventByGroup)
 	r.POST("/gifts/reserve", ctrl.ReserveGift)
+	r.POST("/gifts/pledge", ctrl.PledgeGift)
+	r.DELETE("/invites/:uuid/reservations/:id", ctrl.CancelReservation)
+	r.POST("/invites/:uuid/reservations/:id/swap", ctrl.SwapReservation)
 
 	auth := r.Group("/api")
-	auth.Use(middleware.AuthMiddleware())
+	auth.Use(middleware.AuthMiddleware(db))
 	{
+		auth.POST("/logout", func(c *gin.Context) { controllers.Logout(c, db) })
+		auth.POST("/logout/all", func(c *gin.Context) { controllers.LogoutAll(c, db) })
+		auth.POST("/email/verify/resend", func(c *gin.Context) { controllers.ResendVerification(c, db, mailer) })
 		auth.POST("/events", ctrl.CreateEvent)
 		auth.PUT("/events/:id", ctrl.UpdateEvent)
 		auth.DELETE("/events/:id", ctrl.DeleteEvent)
+		auth.POST("/events/:id/status", ctrl.ChangeEventStatus)
 		auth.GET("/events/:id", ctrl.GetEvent)
+		auth.GET("/calendar/feed", ctrl.GetCalendarFeedURL)
+		auth.POST("/calendar/feed/reset", ctrl.ResetCalendarFeedURL)
+		auth.GET("/events/:id/export", ctrl.ExportGuests)
 
 		auth.POST("/events/:id/invited", ctrl.AddInvited)
+		auth.POST("/events/:id/invited/import", ctrl.ImportInvited)
+		auth.PATCH("/events/:id/invited/:invite_id", ctrl.UpdateInvited)
 		auth.DELETE("/events/:id/invited/:invite_id", ctrl.RemoveInvited)
 
 		auth.POST("/events/:id/gifts", ctrl.AddGift)
+		auth.PATCH("/events/:id/gifts/:gift_id", ctrl.UpdateGift)
 		auth.DELETE("/events/:id/gifts/:gift_id", ctrl.RemoveGift)
+		auth.GET("/events/:id/gifts/export", ctrl.ExportGiftGivers)
+		auth.POST("/events/:id/gifts/export/link", ctrl.GiftGiversLink)
+		auth.PATCH("/events/:id/reservations/:reservation_id", ctrl.UpdateReservationStatus)
+
+		auth.GET("/events/:id/members", ctrl.ListMembers)
+		auth.POST("/events/:id/members", ctrl.InviteMember)
+		auth.PATCH("/events/:id/members/:member_id", ctrl.UpdateMember)
+		auth.DELETE("/events/:id/members/:member_id", ctrl.RemoveMember)
+		auth.POST("/events/:id/transfer", ctrl.TransferOwnership)
+
+		auth.GET("/events/:id/diaper-plan", ctrl.GetDiaperPlan)
+		auth.PUT("/events/:id/diaper-plan", ctrl.UpdateDiaperPlan)
 
 		auth.GET("/events", func(c *gin.Context) {
 			userID := c.GetUint("userID")
 			var events []models.Event
 
+			coHosted := db.Model(&models.EventMember{}).Select("event_id").Where("user_id = ? AND accepted_at IS NOT NULL", userID)
 			if err := db.Preload("Invited").Preload("Gifts.Reservations").
-				Where("user_id = ?", userID).
+				Where("user_id = ? OR id IN (?)", userID, coHosted).
 				Find(&events).Error; err != nil {
 				c.JSON(500, gin.H{"error": err.Error()})
 				return
@@ -0,0 +1,194 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedroShimpa/cha-de-bebe-api/internal/testutil"
+	"github.com/pedroShimpa/cha-de-bebe-api/models"
+	"github.com/pedroShimpa/cha-de-bebe-api/utils"
+	"gorm.io/gorm"
+)
+
+// tenantFixture is user A's event with one of everything, plus user B's token.
+type tenantFixture struct {
+	event       models.Event
+	invite      models.EventInvited
+	group       models.InviteGroup
+	gift        models.EventGift
+	reservation models.GiftReservation
+	member      models.EventMember
+	ownerJWT    string
+	strangerJWT string
+}
+
+func seedTenant(t *testing.T, db *gorm.DB) tenantFixture {
+	t.Helper()
+	now := time.Now()
+	owner := models.User{NomeCompleto: "A", Email: "a@example.com", Senha: "x", VerifiedAt: &now}
+	stranger := models.User{NomeCompleto: "B", Email: "b@example.com", Senha: "x", VerifiedAt: &now}
+	coHost := models.User{NomeCompleto: "C", Email: "c@example.com", Senha: "x", VerifiedAt: &now}
+	for _, u := range []*models.User{&owner, &stranger, &coHost} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f := tenantFixture{}
+	f.event = models.Event{UserID: owner.ID, Status: models.EventPublished, Type: models.NotDefined,
+		Title: "Chá da Ana", Address: "Rua A, 1", StartsAt: now.AddDate(0, 1, 0), Timezone: utils.DefaultTimezone}
+	must(t, db.Create(&f.event).Error)
+	f.group = models.InviteGroup{EventID: f.event.ID, Name: "Família Souza", UUID: utils.GenerateCustomUUID()}
+	must(t, db.Create(&f.group).Error)
+	f.invite = models.EventInvited{EventID: f.event.ID, GroupID: &f.group.ID, Name: "Ana", UUID: utils.GenerateCustomUUID()}
+	must(t, db.Create(&f.invite).Error)
+	f.gift = models.EventGift{EventID: f.event.ID, Name: "Carrinho", Kind: models.GiftItem, MaxReservations: 1}
+	must(t, db.Create(&f.gift).Error)
+	f.reservation = models.GiftReservation{EventGiftID: f.gift.ID, InviteUUID: f.invite.UUID}
+	must(t, db.Create(&f.reservation).Error)
+	f.member = models.EventMember{EventID: f.event.ID, UserID: &coHost.ID, Email: coHost.Email,
+		Role: models.RoleEditor, InvitedBy: owner.ID, AcceptedAt: &now}
+	must(t, db.Create(&f.member).Error)
+
+	f.ownerJWT = sessionJWT(t, db, owner)
+	f.strangerJWT = sessionJWT(t, db, stranger)
+	return f
+}
+
+// sessionJWT signs an access token backed by a live refresh token family, as login does.
+func sessionJWT(t *testing.T, db *gorm.DB, user models.User) string {
+	t.Helper()
+	family := fmt.Sprintf("family-%d", user.ID)
+	must(t, db.Create(&models.RefreshToken{UserID: user.ID, FamilyID: family,
+		TokenHash: utils.HashToken(family), ExpiresAt: time.Now().Add(time.Hour)}).Error)
+	token, err := utils.GenerateToken(user.ID, user.SessionVersion, family)
+	must(t, err)
+	return token
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestEventRoutesHideOtherTenants has user B call every /api/events/:id route on
+// user A's event with a request that would succeed for A.
+func TestEventRoutesHideOtherTenants(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Chdir("..") // templates/ is loaded relative to the repo root
+	db := testutil.OpenDB(t)
+	r := gin.New()
+	SetupRoutes(r, db)
+	f := seedTenant(t, db)
+
+	future := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+	cases := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{"GET", "/api/events/:id", nil},
+		{"PUT", "/api/events/:id", gin.H{"type": "not_defined", "title": "Tomado", "event_date": future, "hour_start": "15:00", "address": "Rua B"}},
+		{"DELETE", "/api/events/:id", nil},
+		{"POST", "/api/events/:id/status", gin.H{"status": "cancelled", "reason": "x"}},
+		{"GET", "/api/events/:id/export", nil},
+		{"POST", "/api/events/:id/invited", gin.H{"name": "Intruso"}},
+		{"POST", "/api/events/:id/invited/import", nil},
+		{"PATCH", "/api/events/:id/invited/:invite_id", gin.H{"name": "Intruso", "version": 1}},
+		{"DELETE", "/api/events/:id/invited/:invite_id", nil},
+		{"POST", "/api/events/:id/gifts", gin.H{"name": "Intruso"}},
+		{"PATCH", "/api/events/:id/gifts/:gift_id", gin.H{"name": "Intruso", "version": 1}},
+		{"DELETE", "/api/events/:id/gifts/:gift_id", nil},
+		{"GET", "/api/events/:id/gifts/export", nil},
+		{"POST", "/api/events/:id/gifts/export/link", nil},
+		{"PATCH", "/api/events/:id/reservations/:reservation_id", gin.H{"received": true}},
+		{"GET", "/api/events/:id/members", nil},
+		{"POST", "/api/events/:id/members", gin.H{"email": "b@example.com", "role": "editor"}},
+		{"PATCH", "/api/events/:id/members/:member_id", gin.H{"role": "viewer"}},
+		{"DELETE", "/api/events/:id/members/:member_id", nil},
+		{"POST", "/api/events/:id/transfer", gin.H{"member_id": f.member.ID}},
+		{"GET", "/api/events/:id/diaper-plan", nil},
+		{"PUT", "/api/events/:id/diaper-plan", gin.H{"targets": gin.H{"P": 10}}},
+	}
+
+	// New event routes must be added to the table above.
+	covered := map[string]bool{}
+	for _, tc := range cases {
+		covered[tc.method+" "+tc.path] = true
+	}
+	for _, route := range r.Routes() {
+		if strings.HasPrefix(route.Path, "/api/events/:id") && !covered[route.Method+" "+route.Path] {
+			t.Errorf("rota %s %s sem caso de teste", route.Method, route.Path)
+		}
+	}
+
+	params := strings.NewReplacer(
+		":invite_id", fmt.Sprint(f.invite.ID),
+		":gift_id", fmt.Sprint(f.gift.ID),
+		":reservation_id", fmt.Sprint(f.reservation.ID),
+		":member_id", fmt.Sprint(f.member.ID),
+		":id", fmt.Sprint(f.event.ID),
+	)
+	call := func(method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, params.Replace(path), &buf)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The owner sees the fixture, so the 404s below come from the tenant check.
+	if w := call("GET", "/api/events/:id", nil, f.ownerJWT); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), f.invite.UUID) {
+		t.Fatalf("dono: status %d: %s", w.Code, w.Body)
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			w := call(tc.method, tc.path, tc.body, f.strangerJWT)
+			if w.Code != http.StatusNotFound && w.Code != http.StatusForbidden {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+			for _, secret := range []string{f.invite.UUID, f.group.UUID, f.event.Title} {
+				if strings.Contains(w.Body.String(), secret) {
+					t.Fatalf("resposta vaza %q: %s", secret, w.Body)
+				}
+			}
+		})
+	}
+
+	// A non-numeric ID must not reach the query as raw SQL, even for the owner.
+	for _, tc := range cases {
+		path := strings.Replace(tc.path, ":id", "999%20OR%201=1", 1)
+		t.Run("injeção "+tc.method+" "+tc.path, func(t *testing.T) {
+			if w := call(tc.method, path, tc.body, f.ownerJWT); w.Code != http.StatusNotFound {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+		})
+	}
+
+	var event models.Event
+	must(t, db.First(&event, f.event.ID).Error)
+	var invites, gifts, reservations, members int64
+	db.Model(&models.EventInvited{}).Where("event_id = ?", f.event.ID).Count(&invites)
+	db.Model(&models.EventGift{}).Where("event_id = ?", f.event.ID).Count(&gifts)
+	db.Model(&models.GiftReservation{}).Where("received_at IS NULL").Count(&reservations)
+	db.Model(&models.EventMember{}).Where("event_id = ? AND role = ?", f.event.ID, models.RoleEditor).Count(&members)
+	if event.Title != f.event.Title || event.Status != models.EventPublished || event.UserID != f.event.UserID ||
+		invites != 1 || gifts != 1 || reservations != 1 || members != 1 {
+		t.Fatalf("evento de A alterado por B: %+v, %d convites, %d presentes, %d reservas, %d organizadores",
+			event, invites, gifts, reservations, members)
+	}
+}
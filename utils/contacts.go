@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// ImportedContact is a raw guest row read from a CSV or vCard file, before validation.
+type ImportedContact struct {
+	Row        int
+	Name       string
+	Whatsapp   string
+	Email      string
+	Group      string
+	Companions string
+}
+
+var csvColumns = map[string]string{
+	"name":          "name",
+	"nome":          "name",
+	"whatsapp":      "whatsapp",
+	"telefone":      "whatsapp",
+	"phone":         "whatsapp",
+	"celular":       "whatsapp",
+	"email":         "email",
+	"e-mail":        "email",
+	"group":         "group",
+	"grupo":         "group",
+	"familia":       "group",
+	"família":       "group",
+	"companions":    "companions",
+	"acompanhantes": "companions",
+}
+
+// ParseContactsCSV reads a CSV with a header row. Both "," and ";" (the Excel
+// default in pt-BR) are accepted as separators.
+func ParseContactsCSV(data []byte) ([]ImportedContact, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("Arquivo CSV vazio ou inválido")
+	}
+
+	columns := make([]string, len(header))
+	hasName := false
+	for i, h := range header {
+		columns[i] = csvColumns[strings.ToLower(strings.TrimSpace(h))]
+		hasName = hasName || columns[i] == "name"
+	}
+	if !hasName {
+		return nil, errors.New("O CSV precisa de uma coluna \"nome\"")
+	}
+
+	var contacts []ImportedContact
+	row := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		row++
+		if err != nil {
+			return nil, fmt.Errorf("Erro ao ler o CSV na linha %d", row)
+		}
+
+		contact := ImportedContact{Row: row}
+		empty := true
+		for i, value := range record {
+			if i >= len(columns) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			empty = empty && value == ""
+			switch columns[i] {
+			case "name":
+				contact.Name = value
+			case "whatsapp":
+				contact.Whatsapp = value
+			case "email":
+				contact.Email = value
+			case "group":
+				contact.Group = value
+			case "companions":
+				contact.Companions = value
+			}
+		}
+		if !empty {
+			contacts = append(contacts, contact)
+		}
+	}
+	return contacts, nil
+}
+
+// ParseVCards reads the contacts of a .vcf file as exported by Android and iOS phones.
+func ParseVCards(data []byte) ([]ImportedContact, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var contacts []ImportedContact
+	var current *ImportedContact
+	var structuredName string
+
+	for _, line := range unfoldVCardLines(data) {
+		prop, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		params := strings.Split(prop, ";")
+		name := strings.ToUpper(params[0])
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:] // grouped properties such as "item1.TEL"
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			current = &ImportedContact{Row: len(contacts) + 1}
+			structuredName = ""
+			continue
+		case name == "END" && strings.EqualFold(value, "VCARD"):
+			if current != nil {
+				if current.Name == "" {
+					current.Name = structuredName
+				}
+				contacts = append(contacts, *current)
+			}
+			current = nil
+			continue
+		case current == nil:
+			continue
+		}
+
+		value = decodeVCardValue(params[1:], value)
+		switch name {
+		case "FN":
+			current.Name = strings.TrimSpace(value)
+		case "N":
+			parts := strings.Split(value, ";")
+			if len(parts) > 1 {
+				structuredName = strings.TrimSpace(parts[1] + " " + parts[0])
+			} else {
+				structuredName = strings.TrimSpace(parts[0])
+			}
+		case "TEL":
+			if current.Whatsapp == "" {
+				current.Whatsapp = strings.TrimPrefix(strings.TrimSpace(value), "tel:")
+			}
+		case "EMAIL":
+			if current.Email == "" {
+				current.Email = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	if len(contacts) == 0 {
+		return nil, errors.New("Nenhum contato encontrado no arquivo vCard")
+	}
+	return contacts, nil
+}
+
+func unfoldVCardLines(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			lines[len(lines)-1] += line[1:]
+		case len(lines) > 0 && strings.HasSuffix(lines[len(lines)-1], "=") &&
+			strings.Contains(strings.ToUpper(lines[len(lines)-1]), "QUOTED-PRINTABLE"):
+			// vCard 2.1 soft line break inside a quoted-printable value
+			lines[len(lines)-1] = strings.TrimSuffix(lines[len(lines)-1], "=") + line
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func decodeVCardValue(params []string, value string) string {
+	for _, p := range params {
+		if strings.EqualFold(p, "ENCODING=QUOTED-PRINTABLE") || strings.EqualFold(p, "QUOTED-PRINTABLE") {
+			if decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(value))); err == nil {
+				value = string(decoded)
+			}
+		}
+	}
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(value)
+}
+
+// NormalizePhone keeps only digits and adds Brazil's country code to local numbers.
+func NormalizePhone(phone string) string {
+	digits := onlyDigits(phone)
+	if len(digits) == 10 || len(digits) == 11 {
+		digits = "55" + digits
+	}
+	return digits
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseContactsCSV(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    string
+		want    []ImportedContact
+		wantErr string
+	}{
+		{
+			name: "cabeçalho em português com ponto e vírgula",
+			data: "\xef\xbb\xbfNome;Telefone;E-mail;Família\nAna Souza;(11) 98765-4321;ana@example.com;Souza\n",
+			want: []ImportedContact{{Row: 2, Name: "Ana Souza", Whatsapp: "(11) 98765-4321", Email: "ana@example.com", Group: "Souza"}},
+		},
+		{
+			name: "cabeçalho em inglês com vírgula e coluna desconhecida",
+			data: "name,notes,phone,companions\nBia,vegetariana,11987654321,2\n",
+			want: []ImportedContact{{Row: 2, Name: "Bia", Whatsapp: "11987654321", Companions: "2"}},
+		},
+		{
+			name: "campos entre aspas com separador dentro",
+			data: "nome,grupo\n\"Silva, Carla\",\"Tios; Primos\"\n",
+			want: []ImportedContact{{Row: 2, Name: "Silva, Carla", Group: "Tios; Primos"}},
+		},
+		{
+			name: "linhas vazias são ignoradas sem perder a numeração",
+			data: "nome;whatsapp\r\nAna;\r\n;\r\nBia;11987654321\r\n",
+			want: []ImportedContact{{Row: 2, Name: "Ana"}, {Row: 4, Name: "Bia", Whatsapp: "11987654321"}},
+		},
+		{
+			name: "linha sem nome continua no relatório",
+			data: "nome,email\n,sem-nome@example.com\n",
+			want: []ImportedContact{{Row: 2, Email: "sem-nome@example.com"}},
+		},
+		{
+			name:    "sem coluna de nome",
+			data:    "telefone,email\n11987654321,ana@example.com\n",
+			wantErr: "O CSV precisa de uma coluna \"nome\"",
+		},
+		{
+			name:    "arquivo vazio",
+			data:    "",
+			wantErr: "Arquivo CSV vazio ou inválido",
+		},
+		{
+			name:    "aspas sem fechamento",
+			data:    "nome\nAna\n\"Bia\n",
+			wantErr: "Erro ao ler o CSV na linha 3",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseContactsCSV([]byte(tc.data))
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("erro %v; esperava %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("contatos %+v; esperava %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseVCards(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    string
+		want    []ImportedContact
+		wantErr string
+	}{
+		{
+			name: "linhas dobradas e telefone agrupado do iOS",
+			data: "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:Ana Maria\r\n  Souza\r\nitem1.TEL;type=CELL:+55 11 98765-4321\r\nTEL:11 3333-4444\r\nEMAIL:ana@example.com\r\nEND:VCARD\r\n",
+			want: []ImportedContact{{Row: 1, Name: "Ana Maria Souza", Whatsapp: "+55 11 98765-4321", Email: "ana@example.com"}},
+		},
+		{
+			name: "quoted-printable com quebra suave do Android",
+			data: "BEGIN:VCARD\nVERSION:2.1\nFN;CHARSET=UTF-8;ENCODING=QUOTED-PRINTABLE:Jo=C3=A3o Concei=\n=C3=A7=C3=A3o\nTEL;CELL:tel:11987654321\nEND:VCARD\n",
+			want: []ImportedContact{{Row: 1, Name: "João Conceição", Whatsapp: "11987654321"}},
+		},
+		{
+			name: "nome estruturado quando falta FN",
+			data: "BEGIN:VCARD\nN:Lima;Carla;;;\nEND:VCARD\nBEGIN:VCARD\nFN:Bia\\, a tia\nEND:VCARD\n",
+			want: []ImportedContact{{Row: 1, Name: "Carla Lima"}, {Row: 2, Name: "Bia, a tia"}},
+		},
+		{
+			name: "contatos repetidos ficam para o relatório",
+			data: "BEGIN:VCARD\nFN:Ana\nEND:VCARD\nBEGIN:VCARD\nFN:Ana\nEND:VCARD\n",
+			want: []ImportedContact{{Row: 1, Name: "Ana"}, {Row: 2, Name: "Ana"}},
+		},
+		{
+			name:    "sem nenhum cartão",
+			data:    "FN:Ana\nTEL:11987654321\n",
+			wantErr: "Nenhum contato encontrado no arquivo vCard",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseVCards([]byte(tc.data))
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("erro %v; esperava %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("contatos %+v; esperava %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	cases := map[string]string{
+		"(11) 98765-4321":   "5511987654321",
+		"11 3333-4444":      "551133334444",
+		"+55 11 98765-4321": "5511987654321",
+		"98765-4321":        "987654321",
+	}
+	for phone, want := range cases {
+		if got := NormalizePhone(phone); got != want {
+			t.Errorf("NormalizePhone(%q) = %q; esperava %q", phone, got, want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// WriteCSV writes a UTF-8 CSV with BOM and ";" separators, which is what Excel
+// expects in pt-BR to open accents and columns correctly.
+func WriteCSV(w io.Writer, header []string, rows [][]string) error {
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	cw.UseCRLF = true
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = spreadsheetCell(value)
+		}
+		if err := cw.Write(cells); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// spreadsheetCell neutralizes values a spreadsheet would run as a formula, since
+// names and e-mails come straight from guests.
+func spreadsheetCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// WriteXLSX writes a single-sheet workbook using inline strings only.
+func WriteXLSX(w io.Writer, sheetName string, header []string, rows [][]string) error {
+	zw := zip.NewWriter(w)
+
+	var sheet strings.Builder
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range append([][]string{header}, rows...) {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, value := range row {
+			fmt.Fprintf(&sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, xlsxColumn(j), i+1)
+			xml.EscapeText(&sheet, []byte(spreadsheetCell(value)))
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var escapedName strings.Builder
+	xml.EscapeText(&escapedName, []byte(sheetName))
+
+	files := []struct{ name, body string }{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + escapedName.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// WriteTablePDF renders a landscape A4 table, wrapping long cells and repeating
+// the header on every page. widths are relative and scaled to the page.
+func WriteTablePDF(w io.Writer, title string, header []string, widths []float64, rows [][]string) error {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(false, 10)
+
+	pageW, pageH := pdf.GetPageSize()
+	total := 0.0
+	for _, cw := range widths {
+		total += cw
+	}
+	cols := make([]float64, len(widths))
+	for i, cw := range widths {
+		cols[i] = cw * (pageW - 20) / total
+	}
+
+	const lineH = 5.0
+	drawRow := func(cells []string, bold bool) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, 8)
+
+		lines := make([][]string, len(cells))
+		height := lineH
+		for i, cell := range cells {
+			lines[i] = pdf.SplitText(cp1252Runes(tr(cell)), cols[i])
+			if h := float64(len(lines[i])) * lineH; h > height {
+				height = h
+			}
+		}
+
+		_, y := pdf.GetXY()
+		if y+height > pageH-10 {
+			pdf.AddPage()
+			_, y = pdf.GetXY()
+		}
+		x := 10.0
+		for i := range cells {
+			pdf.Rect(x, y, cols[i], height, "D")
+			for j, line := range lines[i] {
+				pdf.SetXY(x, y+float64(j)*lineH)
+				pdf.CellFormat(cols[i], lineH, cp1252Bytes(line), "", 0, "L", false, 0, "")
+			}
+			x += cols[i]
+		}
+		pdf.SetXY(10, y+height)
+	}
+
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 10, tr(title), "", 1, "L", false, 0, "")
+		drawRow(header, true)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetXY(10, pageH-8)
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.CellFormat(0, 4, tr(fmt.Sprintf("Página %d", pdf.PageNo())), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	for _, row := range rows {
+		drawRow(row, false)
+	}
+	return pdf.Output(w)
+}
+
+// The core fonts index their width table by cp1252 code, so SplitText must get
+// the translated text with one rune per byte; anything above U+00FF panics.
+// Runes the code page lacks, such as emoji, are already replaced by tr.
+func cp1252Runes(translated string) string {
+	runes := make([]rune, len(translated))
+	for i := 0; i < len(translated); i++ {
+		runes[i] = rune(translated[i])
+	}
+	return string(runes)
+}
+
+func cp1252Bytes(line string) string {
+	out := make([]byte, 0, len(line))
+	for _, r := range line {
+		out = append(out, byte(r))
+	}
+	return string(out)
+}
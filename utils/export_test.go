@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-pdf/fpdf"
+)
+
+var formulaRows = [][]string{
+	{"=HYPERLINK(\"http://x\")", "+5511999999999", "-1", "@SUM(A1)", "Ana"},
+}
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []string{"=Nome", "b", "c", "d", "e"}, formulaRows); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimPrefix(buf.String(), "\xef\xbb\xbf"), "\r\n")
+	if lines[0] != "=Nome;b;c;d;e" {
+		t.Fatalf("cabeçalho alterado: %q", lines[0])
+	}
+	want := `"'=HYPERLINK(""http://x"")";'+5511999999999;'-1;'@SUM(A1);Ana`
+	if lines[1] != want {
+		t.Fatalf("linha %q, esperado %q", lines[1], want)
+	}
+}
+
+func TestWriteXLSXEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, "Planilha", []string{"a", "b", "c", "d", "e"}, formulaRows); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, _ := f.Open()
+		sheet, _ := io.ReadAll(rc)
+		rc.Close()
+		for _, cell := range []string{"&#39;=HYPERLINK", "&#39;+5511", "&#39;-1", "&#39;@SUM", ">Ana<"} {
+			if !strings.Contains(string(sheet), cell) {
+				t.Fatalf("planilha sem %q: %s", cell, sheet)
+			}
+		}
+		return
+	}
+	t.Fatal("planilha não encontrada")
+}
+
+func TestWriteTablePDFOutsideLatin1(t *testing.T) {
+	rows := [][]string{
+		{"Ana 🎉 D’Ávila", "“Carrinho de bebê – R$ 500” — presente da família", "Confirmado"},
+		{strings.Repeat("Bia “Bê” Souza – ", 20), "🍼👶", "…"},
+	}
+	var buf bytes.Buffer
+	if err := WriteTablePDF(&buf, "Convidados – Chá da Ana 🎉", []string{"Nome", "Presente", "Situação"}, []float64{2, 3, 1}, rows); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("saída não é um PDF: %q", buf.Bytes()[:16])
+	}
+}
+
+func TestCP1252RoundTrip(t *testing.T) {
+	tr := fpdf.New("L", "mm", "A4", "").UnicodeTranslatorFromDescriptor("")
+	translated := tr("D’Ávila – “bebê” 🎉")
+	runes := cp1252Runes(translated)
+	for _, r := range runes {
+		if r > 0xFF {
+			t.Fatalf("runa %U fora da tabela da fonte", r)
+		}
+	}
+	if cp1252Bytes(runes) != translated {
+		t.Fatalf("conversão perdeu bytes: %q", cp1252Bytes(runes))
+	}
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"strings"
+	"time"
+)
+
+type ICSEvent struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Cancelled   bool
+}
+
+const icsUTCLayout = "20060102T150405Z"
+
+// BuildICS renders an RFC 5545 calendar. Times are written in UTC, which every
+// client converts correctly across DST changes without a VTIMEZONE, and every
+// event carries a reminder the day before.
+func BuildICS(calendarName string, events []ICSEvent) string {
+	var sb strings.Builder
+	line := func(s string) {
+		sb.WriteString(foldICSLine(s))
+		sb.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Cha de Bebe//Convites//PT-BR")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeICSText(calendarName))
+
+	stamp := time.Now().UTC().Format(icsUTCLayout)
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp)
+		line(icsTime("DTSTART", e.Start))
+		line(icsTime("DTEND", e.End))
+		line("SUMMARY:" + escapeICSText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escapeICSText(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION:" + escapeICSText(e.Location))
+		}
+		if e.URL != "" {
+			line("URL:" + e.URL)
+		}
+		if e.Cancelled {
+			line("STATUS:CANCELLED")
+		} else {
+			line("STATUS:CONFIRMED")
+		}
+		line("BEGIN:VALARM")
+		line("ACTION:DISPLAY")
+		line("TRIGGER:-P1D")
+		line("DESCRIPTION:" + escapeICSText("Amanhã: "+e.Summary))
+		line("END:VALARM")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return sb.String()
+}
+
+func icsTime(prop string, t time.Time) string {
+	return prop + ":" + t.UTC().Format(icsUTCLayout)
+}
+
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldICSLine splits content lines longer than 75 octets without breaking UTF-8 sequences.
+func foldICSLine(s string) string {
+	if len(s) <= 75 {
+		return s
+	}
+	var sb strings.Builder
+	lineLen := 0
+	for _, r := range s {
+		size := len(string(r))
+		if lineLen+size > 75 {
+			sb.WriteString("\r\n ")
+			lineLen = 1
+		}
+		sb.WriteRune(r)
+		lineLen += size
+	}
+	return sb.String()
+}
@@ -17,12 +17,60 @@ func init() {
     JwtSecret = []byte(secret)
 }
 
-func GenerateToken(userID uint) (string, error) {
+// AccessTokenTTL is kept short; clients renew access tokens with a refresh token.
+const AccessTokenTTL = 15 * time.Minute
+
+// GenerateToken signs an access token for the user. sessionVersion must match the
+// user's current one and sessionID names the refresh token family it belongs to,
+// so logging out of that session also rejects the token.
+func GenerateToken(userID uint, sessionVersion uint, sessionID string) (string, error) {
     claims := jwt.MapClaims{
         "user_id": userID,
-        "exp":     time.Now().Add(time.Hour * 72).Unix(),
+        "sv":      sessionVersion,
+        "sid":     sessionID,
+        "exp":     time.Now().Add(AccessTokenTTL).Unix(),
     }
 
     token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
     return token.SignedString(JwtSecret)
 }
+
+// ExportLinkTTL bounds how long a shared export link can be opened.
+const ExportLinkTTL = 10 * time.Minute
+
+// exportPurpose marks link tokens so they're never accepted as access tokens.
+const exportPurpose = "export"
+
+// GenerateExportToken signs a short-lived token that opens one event's export
+// in a browser, where the Authorization header can't be sent.
+func GenerateExportToken(userID, eventID, sessionVersion uint) (string, error) {
+    claims := jwt.MapClaims{
+        "user_id":  userID,
+        "event_id": eventID,
+        "sv":       sessionVersion,
+        "purpose":  exportPurpose,
+        "exp":      time.Now().Add(ExportLinkTTL).Unix(),
+    }
+
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+    return token.SignedString(JwtSecret)
+}
+
+// ParseExportToken validates a token from GenerateExportToken.
+func ParseExportToken(tokenString string) (userID, eventID, sessionVersion uint, err error) {
+    token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+        return JwtSecret, nil
+    }, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+    if err != nil {
+        return 0, 0, 0, err
+    }
+
+    claims, ok := token.Claims.(jwt.MapClaims)
+    if !ok || claims["purpose"] != exportPurpose {
+        return 0, 0, 0, jwt.ErrTokenInvalidClaims
+    }
+    uid, _ := claims["user_id"].(float64)
+    eid, _ := claims["event_id"].(float64)
+    sv, _ := claims["sv"].(float64)
+    return uint(uid), uint(eid), uint(sv), nil
+}
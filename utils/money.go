@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatBRL formats an amount in cents the Brazilian way, e.g. "R$ 1.200,50".
+func FormatBRL(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	reais := fmt.Sprintf("%d", cents/100)
+	var sb strings.Builder
+	for i, r := range reais {
+		if i > 0 && (len(reais)-i)%3 == 0 {
+			sb.WriteRune('.')
+		}
+		sb.WriteRune(r)
+	}
+	return fmt.Sprintf("%sR$ %s,%02d", sign, sb.String(), cents%100)
+}
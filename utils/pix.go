@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+type PixKeyType string
+
+const (
+	PixKeyCPF   PixKeyType = "cpf"
+	PixKeyCNPJ  PixKeyType = "cnpj"
+	PixKeyEmail PixKeyType = "email"
+	PixKeyPhone PixKeyType = "phone"
+	PixKeyEVP   PixKeyType = "evp"
+)
+
+var (
+	ErrInvalidPixKey = errors.New("Chave Pix inválida")
+	// ErrPixPhoneFormat is returned for bare 11-digit keys that aren't a valid CPF,
+	// which are almost always a phone typed without the country code.
+	ErrPixPhoneFormat = errors.New("Chave Pix inválida: telefones devem ser informados no formato +55DDDNÚMERO")
+
+	pixEmailRegex = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	pixPhoneRegex = regexp.MustCompile(`^\+55\d{10,11}$`)
+	pixEVPRegex   = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	pixTxIDRegex  = regexp.MustCompile(`[^A-Za-z0-9]`)
+)
+
+// NormalizePixKey detects the key type and returns it in the format expected by the BR Code.
+func NormalizePixKey(key string) (string, PixKeyType, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", "", ErrInvalidPixKey
+	}
+
+	if strings.Contains(key, "@") {
+		key = strings.ToLower(key)
+		if !pixEmailRegex.MatchString(key) || len(key) > 77 {
+			return "", "", ErrInvalidPixKey
+		}
+		return key, PixKeyEmail, nil
+	}
+
+	if evp := strings.ToLower(key); pixEVPRegex.MatchString(evp) {
+		return evp, PixKeyEVP, nil
+	}
+
+	if strings.HasPrefix(key, "+") {
+		phone := "+" + onlyDigits(key)
+		if !pixPhoneRegex.MatchString(phone) {
+			return "", "", ErrInvalidPixKey
+		}
+		return phone, PixKeyPhone, nil
+	}
+
+	digits := onlyDigits(key)
+	if strings.Trim(key, "0123456789.-/ ") != "" {
+		return "", "", ErrInvalidPixKey
+	}
+	// Phones must come in E.164 (handled above), so a bare 11-digit key is only
+	// accepted when its CPF check digits match.
+	switch {
+	case len(digits) == 11 && validCPF(digits):
+		return digits, PixKeyCPF, nil
+	case len(digits) == 11, len(digits) == 10:
+		return "", "", ErrPixPhoneFormat
+	case len(digits) == 14 && validCNPJ(digits):
+		return digits, PixKeyCNPJ, nil
+	}
+	return "", "", ErrInvalidPixKey
+}
+
+// Fallbacks for the mandatory BR Code fields, which readers reject when empty.
+const (
+	defaultPixMerchantName = "RECEBEDOR PIX"
+	defaultPixMerchantCity = "BRASIL"
+)
+
+type PixPayload struct {
+	Key          string
+	MerchantName string
+	MerchantCity string
+	AmountCents  int64
+	TxID         string
+}
+
+// BuildPixPayload assembles a static Pix BR Code (EMV MPM) ready to be copied or encoded as a QR code.
+func BuildPixPayload(p PixPayload) string {
+	txID := pixTxIDRegex.ReplaceAllString(p.TxID, "")
+	if len(txID) > 25 {
+		txID = txID[:25]
+	}
+	if txID == "" {
+		txID = "***"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(emvField("00", "01"))
+	sb.WriteString(emvField("26", emvField("00", "br.gov.bcb.pix")+emvField("01", p.Key)))
+	sb.WriteString(emvField("52", "0000"))
+	sb.WriteString(emvField("53", "986"))
+	if p.AmountCents > 0 {
+		sb.WriteString(emvField("54", fmt.Sprintf("%d.%02d", p.AmountCents/100, p.AmountCents%100)))
+	}
+	sb.WriteString(emvField("58", "BR"))
+	sb.WriteString(emvField("59", emvTextOr(p.MerchantName, 25, defaultPixMerchantName)))
+	sb.WriteString(emvField("60", emvTextOr(p.MerchantCity, 15, defaultPixMerchantCity)))
+	sb.WriteString(emvField("62", emvField("05", txID)))
+	sb.WriteString("6304")
+
+	payload := sb.String()
+	return payload + fmt.Sprintf("%04X", crc16CCITT([]byte(payload)))
+}
+
+func emvField(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// emvText strips accents and truncates the value, since the BR Code only accepts ASCII.
+func emvText(value string, max int) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	ascii, _, _ := transform.String(t, value)
+
+	var sb strings.Builder
+	for _, r := range strings.TrimSpace(ascii) {
+		if r < 128 {
+			sb.WriteRune(r)
+		}
+	}
+	out := sb.String()
+	if len(out) > max {
+		out = strings.TrimSpace(out[:max])
+	}
+	return out
+}
+
+func emvTextOr(value string, max int, fallback string) string {
+	if out := emvText(value, max); out != "" {
+		return out
+	}
+	return fallback
+}
+
+func crc16CCITT(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func onlyDigits(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func validCPF(cpf string) bool {
+	if strings.Count(cpf, cpf[:1]) == len(cpf) {
+		return false
+	}
+	return checkDigit(cpf[:9], []int{10, 9, 8, 7, 6, 5, 4, 3, 2}) == cpf[9] &&
+		checkDigit(cpf[:10], []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}) == cpf[10]
+}
+
+func validCNPJ(cnpj string) bool {
+	if strings.Count(cnpj, cnpj[:1]) == len(cnpj) {
+		return false
+	}
+	return checkDigit(cnpj[:12], []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) == cnpj[12] &&
+		checkDigit(cnpj[:13], []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) == cnpj[13]
+}
+
+func checkDigit(digits string, weights []int) byte {
+	sum := 0
+	for i, w := range weights {
+		sum += int(digits[i]-'0') * w
+	}
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
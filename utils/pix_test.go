@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePixKey(t *testing.T) {
+	cases := []struct {
+		key      string
+		want     string
+		wantType PixKeyType
+		wantErr  error
+	}{
+		{"529.982.247-25", "52998224725", PixKeyCPF, nil},
+		{"+55 (11) 98765-4321", "+5511987654321", PixKeyPhone, nil},
+		{"11987654321", "", "", ErrPixPhoneFormat},
+		{"11.222.333/0001-81", "11222333000181", PixKeyCNPJ, nil},
+		{"Ana@Example.com", "ana@example.com", PixKeyEmail, nil},
+		{"abc", "", "", ErrInvalidPixKey},
+	}
+	for _, tc := range cases {
+		got, gotType, err := NormalizePixKey(tc.key)
+		if !errors.Is(err, tc.wantErr) || got != tc.want || gotType != tc.wantType {
+			t.Errorf("NormalizePixKey(%q) = %q, %q, %v; esperava %q, %q, %v", tc.key, got, gotType, err, tc.want, tc.wantType, tc.wantErr)
+		}
+	}
+}
+
+func TestBuildPixPayloadFallsBackOnEmptyMerchant(t *testing.T) {
+	payload := BuildPixPayload(PixPayload{Key: "52998224725", MerchantName: "  ", MerchantCity: "ÇÃ"})
+	if strings.Contains(payload, "5900") {
+		t.Fatalf("payload com nome vazio: %s", payload)
+	}
+	if !strings.Contains(payload, "5913RECEBEDOR PIX") {
+		t.Fatalf("payload sem o nome padrão: %s", payload)
+	}
+}
+
+func TestQRCodeSVG(t *testing.T) {
+	svg, err := QRCodeSVG("00020101021126", 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `width="200"`) || !strings.Contains(svg, "M") {
+		t.Fatalf("svg inesperado: %.80s", svg)
+	}
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const DefaultTimezone = "America/Sao_Paulo"
+
+var (
+	ErrInvalidEventDate = errors.New("Data do evento inválida, use dd/mm/aaaa ou aaaa-mm-dd")
+	ErrInvalidEventHour = errors.New("Horário inválido, use hh:mm")
+	ErrInvalidTimezone  = errors.New("Fuso horário inválido")
+	ErrEndBeforeStart   = errors.New("O horário de término deve ser depois do início; para festas que passam da meia-noite, marque overnight ou informe a data de término")
+	ErrInvalidDeadline  = errors.New("Prazo inválido, use dd/mm/aaaa, aaaa-mm-dd ou data e hora ISO")
+
+	eventDateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006"}
+	eventHourLayouts = []string{"15:04", "15:04:05", "15h04", "15h"}
+)
+
+// LoadTimezone resolves an IANA zone name, defaulting to Brasília time.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// EventSchedule is the date and hours typed by the organizer.
+type EventSchedule struct {
+	Date      string
+	HourStart string
+	HourEnd   string
+	// EndDate is the day the party ends, when not the same as Date.
+	EndDate string
+	// Overnight means an end hour not after the start falls on the next day.
+	Overnight bool
+	Timezone  string
+}
+
+// ParseEventSchedule combines the schedule into timestamps in the event's
+// timezone. The dates may also be full ISO datetimes, in which case the
+// matching hour may be empty. An end that isn't after the start is rejected
+// unless Overnight is set, so a typo doesn't turn into a day-long party.
+func ParseEventSchedule(s EventSchedule) (time.Time, *time.Time, error) {
+	loc, err := LoadTimezone(s.Timezone)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	start, err := parseDayAndHour(s.Date, s.HourStart, loc)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if strings.TrimSpace(s.HourEnd) == "" && strings.TrimSpace(s.EndDate) == "" {
+		return start, nil, nil
+	}
+
+	endDate := s.EndDate
+	if strings.TrimSpace(endDate) == "" {
+		endDate = start.Format("2006-01-02")
+	}
+	end, err := parseDayAndHour(endDate, s.HourEnd, loc)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if !end.After(start) && s.Overnight && strings.TrimSpace(s.EndDate) == "" {
+		end = end.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		return time.Time{}, nil, ErrEndBeforeStart
+	}
+	return start, &end, nil
+}
+
+func parseDayAndHour(date, hour string, loc *time.Location) (time.Time, error) {
+	date = strings.TrimSpace(date)
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		date, hour = t.In(loc).Format("2006-01-02"), t.In(loc).Format("15:04")
+	} else if d, h, ok := strings.Cut(date, "T"); ok {
+		date, hour = d, h
+	}
+
+	var day time.Time
+	var err error
+	for _, layout := range eventDateLayouts {
+		if day, err = time.ParseInLocation(layout, date, loc); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, ErrInvalidEventDate
+	}
+	return atHour(day, hour)
+}
+
+func atHour(day time.Time, hour string) (time.Time, error) {
+	hour = strings.ToLower(strings.TrimSpace(hour))
+	for _, layout := range eventHourLayouts {
+		if h, err := time.Parse(layout, hour); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), h.Hour(), h.Minute(), 0, 0, day.Location()), nil
+		}
+	}
+	return time.Time{}, ErrInvalidEventHour
+}
+
+// ParseDeadline reads a cut-off date in the event's timezone. A date without a
+// time means the whole day is still allowed.
+func ParseDeadline(value, timezone string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	loc, err := LoadTimezone(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", value, loc); err == nil {
+		return &t, nil
+	}
+	for _, layout := range eventDateLayouts {
+		if day, err := time.ParseInLocation(layout, value, loc); err == nil {
+			endOfDay := day.AddDate(0, 0, 1).Add(-time.Second)
+			return &endOfDay, nil
+		}
+	}
+	return nil, ErrInvalidDeadline
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEventSchedule(t *testing.T) {
+	loc, _ := LoadTimezone(DefaultTimezone)
+	at := func(day, hour, min int) time.Time { return time.Date(2026, 11, day, hour, min, 0, 0, loc) }
+
+	cases := []struct {
+		name      string
+		schedule  EventSchedule
+		wantStart time.Time
+		wantEnd   *time.Time
+	}{
+		{"sem término", EventSchedule{Date: "21/11/2026", HourStart: "15:00"}, at(21, 15, 0), nil},
+		{"mesmo dia", EventSchedule{Date: "2026-11-21", HourStart: "15h", HourEnd: "18:30"}, at(21, 15, 0), ptr(at(21, 18, 30))},
+		{"virando a noite", EventSchedule{Date: "21/11/2026", HourStart: "20:00", HourEnd: "01:00", Overnight: true}, at(21, 20, 0), ptr(at(22, 1, 0))},
+		{"com data de término", EventSchedule{Date: "21/11/2026", HourStart: "20:00", EndDate: "22/11/2026", HourEnd: "02:00"}, at(21, 20, 0), ptr(at(22, 2, 0))},
+		{"ISO com término ISO", EventSchedule{Date: "2026-11-21T20:00", EndDate: "2026-11-22T02:00"}, at(21, 20, 0), ptr(at(22, 2, 0))},
+	}
+	for _, tc := range cases {
+		tc.schedule.Timezone = DefaultTimezone
+		t.Run(tc.name, func(t *testing.T) {
+			start, end, err := ParseEventSchedule(tc.schedule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !start.Equal(tc.wantStart) {
+				t.Fatalf("início %v, esperado %v", start, tc.wantStart)
+			}
+			if (end == nil) != (tc.wantEnd == nil) || end != nil && !end.Equal(*tc.wantEnd) {
+				t.Fatalf("término %v, esperado %v", end, tc.wantEnd)
+			}
+		})
+	}
+}
+
+func TestParseEventScheduleRejectsBadInput(t *testing.T) {
+	cases := []struct {
+		name     string
+		schedule EventSchedule
+		want     error
+	}{
+		{"data inválida", EventSchedule{Date: "31/02/2026", HourStart: "15:00"}, ErrInvalidEventDate},
+		{"hora inválida", EventSchedule{Date: "21/11/2026", HourStart: "tarde"}, ErrInvalidEventHour},
+		{"término antes do início", EventSchedule{Date: "21/11/2026", HourStart: "15:00", HourEnd: "14:00"}, ErrEndBeforeStart},
+		{"término igual ao início", EventSchedule{Date: "21/11/2026", HourStart: "15:00", HourEnd: "15:00"}, ErrEndBeforeStart},
+		{"data de término anterior", EventSchedule{Date: "21/11/2026", HourStart: "15:00", EndDate: "20/11/2026", HourEnd: "18:00", Overnight: true}, ErrEndBeforeStart},
+		{"data de término sem hora", EventSchedule{Date: "21/11/2026", HourStart: "15:00", EndDate: "22/11/2026"}, ErrInvalidEventHour},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := ParseEventSchedule(tc.schedule); err != tc.want {
+				t.Fatalf("erro %v, esperado %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time { return &t }